@@ -0,0 +1,70 @@
+package broadcast
+
+import "sync"
+
+// Number is the set of types Aggregate works with.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// An Aggregator keeps a running sum and count of broadcast values, see
+// Aggregate. Its methods are safe for concurrent use.
+type Aggregator[T Number] struct {
+	mu    sync.Mutex
+	sum   T
+	count int
+}
+
+// Aggregate subscribes to b and returns an Aggregator of every value
+// received, along with a cancel func that unsubscribes it. If b is
+// already closed the Aggregator stays empty.
+func Aggregate[T Number](b *Broadcaster[T]) (*Aggregator[T], func()) {
+	a := &Aggregator[T]{}
+
+	cancel, err := b.SubscribeFunc(0, a.add)
+	if err != nil {
+		return a, func() {}
+	}
+
+	return a, cancel
+}
+
+// add records v.
+func (a *Aggregator[T]) add(v T) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.sum += v
+	a.count++
+}
+
+// Sum returns the sum of the values received so far.
+func (a *Aggregator[T]) Sum() T {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.sum
+}
+
+// Count returns the number of values received so far.
+func (a *Aggregator[T]) Count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.count
+}
+
+// Avg returns the average of the values received so far, or 0 if none
+// were.
+func (a *Aggregator[T]) Avg() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.count == 0 {
+		return 0
+	}
+
+	return float64(a.sum) / float64(a.count)
+}
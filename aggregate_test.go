@@ -0,0 +1,52 @@
+package broadcast
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregate(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	agg, cancel := Aggregate(b)
+	defer cancel()
+
+	if avg := agg.Avg(); avg != 0 {
+		t.Errorf("Expected an empty average of 0, got %v", avg)
+	}
+
+	for _, v := range []int{2, 4, 6} {
+		if err := b.Publish(v); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for agg.Count() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for 3 values, got %d", agg.Count())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if sum := agg.Sum(); sum != 12 {
+		t.Errorf("Expected sum 12, got %d", sum)
+	}
+
+	if avg := agg.Avg(); avg != 4 {
+		t.Errorf("Expected average 4, got %v", avg)
+	}
+
+	cancel()
+
+	if err := b.Publish(8); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if n := agg.Count(); n != 3 {
+		t.Errorf("Expected no values after cancel, got %d", n)
+	}
+}
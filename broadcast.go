@@ -7,120 +7,2334 @@
 package broadcast
 
 import (
+	"context"
 	"fmt"
+	"iter"
+	"slices"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // ErrBroadcasterClosed is returned when trying to subscribe to a closed Broadcaster.
 var ErrBroadcasterClosed = fmt.Errorf("broadcaster is closed")
 
+// ErrEvicted is reported to a subscriber that was removed for timing out
+// too often, or with DropSubscriber.
+var ErrEvicted = fmt.Errorf("subscriber was evicted")
+
+// ErrDataLost is returned by SubscribeFrom when some of the requested
+// values are no longer retained.
+var ErrDataLost = fmt.Errorf("requested values are no longer retained")
+
+// ErrTooManySubscribers is returned when subscribing to a Broadcaster
+// that already has as many subscribers as allowed by WithMaxSubscribers.
+var ErrTooManySubscribers = fmt.Errorf("too many subscribers")
+
+// ErrInvalidBufferSize is returned when subscribing or resizing with a
+// negative buffer size.
+var ErrInvalidBufferSize = fmt.Errorf("buffer size must not be negative")
+
+// ErrInvalidCount is returned by FanOut for a negative number of
+// subscriptions.
+var ErrInvalidCount = fmt.Errorf("count must not be negative")
+
+// ErrAlreadySubscribed is returned when subscribing a channel that is
+// already subscribed to the Broadcaster.
+var ErrAlreadySubscribed = fmt.Errorf("channel is already subscribed")
+
+// ErrNilChannel is returned when subscribing a nil channel.
+var ErrNilChannel = fmt.Errorf("channel must not be nil")
+
+// ErrNotSubscribed is returned by UnsubscribeChecked for a channel that
+// is not subscribed to the Broadcaster.
+var ErrNotSubscribed = fmt.Errorf("channel is not subscribed")
+
 // A Broadcaster broadcasts values to multiple subscribers.
+//
+// All methods of a Broadcaster are safe for concurrent use, so values
+// may be published and subscriptions added or removed from any number
+// of goroutines at once.
 type Broadcaster[T any] struct {
-	m           sync.RWMutex // Protects the subscribers slice
-	subscribers map[chan<- T]struct{}
-	valCh       chan T
+	m           sync.RWMutex // Protects the subscribers map and order slice
+	subscribers map[chan<- T]*subscriber[T]
+	order       []*subscriber[T] // Subscribers by descending priority
+	lastID      uint64           // ID of the last subscriber added
+	valCh       chan message[T]
+	inCh        chan T // Forwarded to Publish, see Chan
 	closeCh     chan struct{}
-	timeout     time.Duration
+	closeOnce   sync.Once
+	drainCh     chan chan struct{}
+	resizeCh    chan resize[T]
+	pauseCh     chan bool    // Carries Pause and Resume to the run loop
+	timeout     atomic.Int64 // In nanoseconds, see SetTimeout
+	sequential  bool         // Deliver to one subscriber at a time
+	stats       counters
+	latency     histogram
+	observer    Observer[T]
+
+	// NOTE(njern): Publishers hold pubMu for reading while they enqueue,
+	// so that once stopCh is closed and pubMu has been locked for
+	// writing, no further values can enter valCh.
+	pubMu    sync.RWMutex
+	stopCh   chan struct{} // Closed once new values are rejected
+	stopOnce sync.Once
+
+	doneCh chan struct{}  // Closed once Close has finished
+	err    error          // Set by CloseWithError
+	wg     sync.WaitGroup // Tracks the run loop and forward goroutines
+
+	// NOTE(njern): seq, replay and last are protected by m, see prepare.
+	seq     uint64 // Sequence number of the last broadcast value
+	replay  *ring[Message[T]]
+	sticky  bool
+	last    Message[T]
+	hasLast bool
+
+	onDrop  func(ch chan<- T, v T)
+	dropsMu sync.Mutex // Protects drops and evictions during a broadcast
+	drops   []drop[T]
+
+	ttl         time.Duration
+	interceptor func(v T) (T, bool)
+	deadLetter  func(v T) // Protected by m, see SetDeadLetter
+	clone       atomic.Pointer[func(v T) T]
+	limiter     *limiter
+	logger      Logger
+	dedup       func(v T) bool // Set by NewDedup
+
+	evictAfter int
+	onEvict    func(ch chan<- T)
+	evictions  []*subscriber[T]
+
+	maxSubscribers int
+	inputPolicy    Policy // See WithInputPolicy
+
+	heartbeat      time.Duration // Interval between heartbeats, if any
+	heartbeatValue T
+
+	groups map[string]uint64 // Values handed to each group, see pick
+
+	workers int         // Size of the delivery pool, see WithWorkers
+	jobs    chan job[T] // Hands deliveries to the pool
+
+	joined chan struct{} // Protected by m, see WaitForSubscribers
+
+	// NOTE(njern): budgetEnd is only set by the run loop, before it
+	// delivers each value.
+	budget    time.Duration // See WithBudget
+	budgetEnd time.Time
+
+	minTimeout time.Duration // See WithMinTimeout
+
+	// NOTE(njern): tally is only set by the run loop, while it handles
+	// a message from PublishReceipt, and cleared once delivery is done.
+	tally *tally
+}
+
+// A job is a single delivery handed to the worker pool.
+type job[T any] struct {
+	sub *subscriber[T]
+	v   T
+	seq uint64
+	wg  *sync.WaitGroup
+}
+
+// A message carries one or more published values through the input
+// buffer.
+type message[T any] struct {
+	v     T
+	batch []T           // Set instead of v for PublishBatch
+	tag   string        // Set for PublishTo
+	flush bool          // Set if the message carries no value, see Flush
+	at    time.Time     // When the message was published, if it can expire
+	done  chan struct{} // Closed once the message has been handled
+
+	receipt chan DeliveryReceipt // Set for PublishReceipt
+}
+
+// discard reports to whoever waits on the message that it was dropped
+// without being broadcast.
+func (m message[T]) discard() {
+	if m.done != nil {
+		close(m.done)
+	}
+
+	if m.receipt != nil {
+		m.receipt <- DeliveryReceipt{}
+	}
+}
+
+// A resize asks the run loop to swap the input buffer for ch.
+type resize[T any] struct {
+	ch     chan message[T]
+	locked chan struct{} // Closed once publishers are locked out
+	done   chan struct{} // Closed once the buffer has been swapped
+}
+
+// A drop is a value that a subscriber missed.
+type drop[T any] struct {
+	ch chan<- T
+	v  T
+}
+
+// A Policy decides what happens to a value that a subscriber is not
+// ready to receive.
+type Policy int
+
+const (
+	// DropNewest waits up to the timeout and then drops the new value.
+	DropNewest Policy = iota
+	// DropOldest discards the oldest buffered value to make room for
+	// the new one. Unbuffered subscribers fall back to DropNewest.
+	DropOldest
+	// Block waits until the value is received, ignoring the timeout,
+	// so the subscriber never misses a value. A single slow Block
+	// subscriber stalls delivery to every other subscriber.
+	Block
+	// Disconnect waits up to the timeout and then drops the new value
+	// and evicts the subscriber, closing its channel, as if it had
+	// reached the threshold set by WithEviction.
+	Disconnect
+)
+
+// SubscribeOptions configures a single subscription.
+type SubscribeOptions[T any] struct {
+	// Timeout overrides the broadcaster's timeout for this subscriber.
+	// A zero Timeout uses the broadcaster's, see SetTimeout. Use the
+	// Block policy to wait indefinitely for this subscriber.
+	Timeout time.Duration
+	// Policy decides what happens when the subscriber falls behind.
+	Policy Policy
+	// Priority orders delivery within each broadcast: subscribers with
+	// a higher Priority are served before those with a lower one. It
+	// does not change whether a value is delivered.
+	Priority int
+	// Filter, if set, limits delivery to the values it returns true
+	// for. A Filter that panics is treated as returning false.
+	Filter func(T) bool
+	// Tags label the subscriber so that it also receives the values
+	// published with PublishTo for any of them.
+	Tags []string
+	// Group, if set, adds the subscriber to a queue group. Each value
+	// goes to only one member of a group, taking turns, instead of to
+	// all of them. Group members don't receive replayed values.
+	Group string
+	// Backpressure makes the subscriber wait for every value without a
+	// timeout, like the Block policy, whatever its Policy, so that the
+	// publisher can go no faster than it reads. Other subscribers keep
+	// their own policies, but are held up by it too: a Backpressure
+	// subscriber that stops reading, for example because it waits on
+	// the publisher, deadlocks the broadcaster.
+	Backpressure bool
+	// OnClose, if set, is called once the broadcaster has closed the
+	// subscriber's channel, whether by Unsubscribe, eviction or Close.
+	// It is called exactly once, without holding any internal locks.
+	OnClose func()
+}
+
+// A subscriber holds the delivery settings for a single subscription.
+type subscriber[T any] struct {
+	id       uint64 // Unique within the broadcaster, see Subscriptions
+	out      chan T // The channel handed to the caller
+	ch       chan T // The channel values are sent on
+	timeout  time.Duration
+	policy   Policy
+	priority int
+	filter   func(T) bool
+	tags     []string
+	group    string
+
+	// NOTE(njern): Values are sent on ch without holding the
+	// Broadcaster's lock, so mu serializes sends with closing ch and
+	// done makes a pending send give up once the subscriber is removed.
+	mu     sync.Mutex
+	done   chan struct{}
+	closed bool
+	misses int // Consecutive timeouts
+
+	errCh chan error // Receives the reason the subscription ended, if set
+
+	trackSeqs bool     // Set for subscribers created by SubscribeSeq
+	seqs      []uint64 // Sequence numbers of the values buffered in out
+
+	drain    bool          // Set by UnsubscribeDrain before done is closed
+	keepOpen bool          // Set by CloseKeepOpen before done is closed
+	internal bool          // Never kept open, see CloseKeepOpen
+	borrowed bool          // Never closed, see SubscribeChan
+	drained  chan struct{} // Closed once nothing more is sent on out
+	onClose  func()
+}
+
+// forward delivers backlog to out, followed by everything sent to the
+// subscriber, until the subscriber is closed. It is used when ch is an
+// intermediate channel rather than the one handed to the caller.
+func (s *subscriber[T]) forward(backlog []T, closeCh <-chan struct{}) {
+	defer func() {
+		// NOTE(njern): The caller may have closed out, in which case
+		// sending on it panics. Give up on the values left instead.
+		_ = recover()
+
+		if s.closesOut() {
+			closeChan(s.out)
+			defer s.ended()
+		}
+
+		close(s.drained)
+	}()
+
+	for len(backlog) > 0 {
+		select {
+		case s.out <- backlog[0]:
+			backlog = backlog[1:]
+		case <-s.done:
+			s.flush(backlog, closeCh)
+			return
+		}
+	}
+
+	for {
+		select {
+		case v := <-s.ch:
+			select {
+			case s.out <- v:
+			case <-s.done:
+				s.flush([]T{v}, closeCh)
+				return
+			}
+		case <-s.done:
+			s.flush(nil, closeCh)
+			return
+		}
+	}
+}
+
+// flush delivers rest, followed by the values still buffered in ch, to
+// out if the subscriber was removed by UnsubscribeDrain. It gives up
+// once closeCh is closed.
+func (s *subscriber[T]) flush(rest []T, closeCh <-chan struct{}) {
+	if !s.drain {
+		return
+	}
+
+	for _, v := range rest {
+		select {
+		case s.out <- v:
+		case <-closeCh:
+			return
+		}
+	}
+
+	for {
+		select {
+		case v := <-s.ch:
+			select {
+			case s.out <- v:
+			case <-closeCh:
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// ended runs the subscriber's OnClose callback, if any. It is called
+// once out has been closed, by forward if it closes out and otherwise
+// by whoever removed the subscriber, once it released its locks.
+func (s *subscriber[T]) ended() {
+	if s.onClose != nil {
+		s.onClose()
+	}
+}
+
+// forwarded reports whether out is closed by forward.
+func (s *subscriber[T]) forwarded() bool {
+	return s.ch != s.out
+}
+
+// accepts reports whether the value passes the subscriber's filter.
+func (s *subscriber[T]) accepts(v T) (ok bool) {
+	if s.filter == nil {
+		return true
+	}
+
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	return s.filter(v)
+}
+
+// backlog returns the number of values waiting to be read.
+func (s *subscriber[T]) backlog() int {
+	n := len(s.out)
+	if s.ch != s.out {
+		n += len(s.ch)
+	}
+
+	return n
+}
+
+// close closes the channel handed to the caller, waiting for any
+// pending send to give up first. The reason is reported to subscribers
+// that asked for it.
+func (s *subscriber[T]) close(reason error) {
+	close(s.done)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.errCh != nil {
+		s.errCh <- reason
+		close(s.errCh)
+	}
+
+	if !s.forwarded() {
+		// NOTE(njern): A nil channel can't be closed, see send.
+		if s.ch != nil && s.closesOut() {
+			closeChan(s.ch)
+		}
+
+		close(s.drained)
+	}
+}
+
+// closeChan closes ch. Subscribe hands out a channel the caller can
+// close too, for example before unsubscribing, so it tolerates a
+// channel that is already closed.
+func closeChan[T any](ch chan T) {
+	defer func() {
+		_ = recover()
+	}()
+
+	close(ch)
+}
+
+// closesOut reports whether out is closed once the subscriber is
+// removed, rather than left open, see CloseKeepOpen and SubscribeChan.
+func (s *subscriber[T]) closesOut() bool {
+	return !s.keepOpen && !s.borrowed
 }
 
 // New creates a new Broadcaster with a buffer of size `n`
-// and a timeout for each subscriber of `timeout`.
-func New[T any](n int, timeout time.Duration) *Broadcaster[T] {
+// and a timeout for each subscriber of `timeout`. A zero
+// timeout waits indefinitely for each subscriber.
+func New[T any](n int, timeout time.Duration, opts ...Option[T]) *Broadcaster[T] {
 	b := &Broadcaster[T]{
-		subscribers: make(map[chan<- T]struct{}),
-		valCh:       make(chan T, n),
+		subscribers: make(map[chan<- T]*subscriber[T]),
+		valCh:       make(chan message[T], n),
+		inCh:        make(chan T, n),
 		closeCh:     make(chan struct{}),
-		timeout:     timeout,
+		drainCh:     make(chan chan struct{}),
+		resizeCh:    make(chan resize[T]),
+		pauseCh:     make(chan bool),
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
+		observer:    noopObserver[T]{},
+		inputPolicy: Block,
+	}
+
+	b.timeout.Store(int64(timeout))
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if b.workers > 0 && !b.sequential {
+		b.jobs = make(chan job[T])
+		b.wg.Add(b.workers)
+		for range b.workers {
+			go func() {
+				defer b.wg.Done()
+				b.work()
+			}()
+		}
 	}
 
-	go b.run()
+	b.wg.Add(2)
+	go func() {
+		defer b.wg.Done()
+		b.run()
+	}()
+	go func() {
+		defer b.wg.Done()
+		b.forwardChan()
+	}()
+
 	return b
 }
 
-// run starts the broadcasting process, listening for new values and subscribers.
-func (b *Broadcaster[T]) run() {
+// work runs the deliveries handed to the worker pool until the
+// broadcaster is closed.
+func (b *Broadcaster[T]) work() {
 	for {
 		select {
-		case v := <-b.valCh:
-			b.broadcast(v)
+		case j := <-b.jobs:
+			b.send(j.sub, j.v, j.seq)
+			j.wg.Done()
 		case <-b.closeCh:
 			return
 		}
 	}
 }
 
-// broadcast the value to all subscribers.
-func (b *Broadcaster[T]) broadcast(v T) {
-	b.m.RLock()
-	defer b.m.RUnlock()
+// NewWithTTL is like New with the WithTTL option.
+func NewWithTTL[T any](n int, timeout, ttl time.Duration) *Broadcaster[T] {
+	return New(n, timeout, WithTTL[T](ttl))
+}
+
+// NewDedup is like New but skips every value equal to the one
+// broadcast right before it. The first value is always broadcast.
+func NewDedup[T comparable](n int, timeout time.Duration, opts ...Option[T]) *Broadcaster[T] {
+	var last T
+	var hasLast bool
+	dedup := func(b *Broadcaster[T]) {
+		// NOTE(njern): Only the run loop calls dedup, so last needs
+		// no locking.
+		b.dedup = func(v T) bool {
+			if hasLast && v == last {
+				return false
+			}
+
+			last, hasLast = v, true
+			return true
+		}
+	}
+
+	return New(n, timeout, append(opts, dedup)...)
+}
+
+// NewWithHeartbeat is like New with the WithHeartbeat option.
+func NewWithHeartbeat[T any](n int, timeout, interval time.Duration, v T) *Broadcaster[T] {
+	return New(n, timeout, WithHeartbeat(interval, v))
+}
+
+// NewWithRateLimit is like New with the WithRateLimit option, keeping
+// excess values in the buffer.
+func NewWithRateLimit[T any](n int, timeout time.Duration, maxPerSec int) *Broadcaster[T] {
+	return New(n, timeout, WithRateLimit[T](maxPerSec, false))
+}
+
+// run starts the broadcasting process, listening for new values and subscribers.
+func (b *Broadcaster[T]) run() {
+	var heartbeat *time.Timer
+	if b.heartbeat > 0 {
+		heartbeat = time.NewTimer(b.heartbeat)
+		defer heartbeat.Stop()
+	}
+
+	paused := false
+	for {
+		// NOTE(njern): A nil channel is never ready, so values stay in
+		// the buffer while paused.
+		valCh := b.valCh
+		var heartbeatC <-chan time.Time
+		if heartbeat != nil {
+			heartbeatC = heartbeat.C
+		}
+		if paused {
+			valCh, heartbeatC = nil, nil
+		}
 
-	for ch := range b.subscribers {
 		select {
-		case ch <- v:
-		case <-time.After(b.timeout):
-			// NOTE(njern): The subscriber did not read from the
-			// channel within the timeout, keep going.
+		case m := <-valCh:
+			b.safely(func() { b.handle(m) })
+
+			if heartbeat != nil && !m.flush {
+				heartbeat.Reset(b.heartbeat)
+			}
+		case <-heartbeatC:
+			b.safely(func() { b.broadcastTo("", []T{b.heartbeatValue}, false) })
+			heartbeat.Reset(b.heartbeat)
+		case paused = <-b.pauseCh:
+		case done := <-b.drainCh:
+			b.safely(b.drain)
+			close(done)
+		case r := <-b.resizeCh:
+			// NOTE(njern): Messages that don't fit the new buffer are
+			// broadcast once Resize has let publishers back in.
+			for _, m := range b.resize(r) {
+				b.safely(func() { b.handle(m) })
+			}
 		case <-b.closeCh:
-			// NOTE(njern): Handle an edge case where the
-			// Broadcaster is closed while broadcasting.
 			return
 		}
 	}
 }
 
-// Subscribe adds a new subscriber to the broadcaster and returns a channel to listen on.
-func (b *Broadcaster[T]) Subscribe(chSize int) (chan T, error) {
-	b.m.Lock()
-	defer b.m.Unlock()
+// safely runs fn on behalf of the run loop, recovering from a panic in
+// it, for example in the callback set by WithOnDrop, so that a single
+// bad broadcast doesn't stop the broadcaster for good. Recovered panics
+// are logged and counted in Stats.
+func (b *Broadcaster[T]) safely(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.recovered(r)
+		}
+	}()
 
-	if b.isClosed() {
-		return nil, ErrBroadcasterClosed
-	}
+	fn()
+}
 
-	ch := make(chan T, chSize)
-	b.subscribers[ch] = struct{}{}
-	return ch, nil
+// recovered logs and counts a panic recovered by safely or send.
+func (b *Broadcaster[T]) recovered(r any) {
+	b.stats.panics.Add(1)
+	b.logf("broadcast: recovered from panic: %v", r)
 }
 
-// Unsubscribe removes a subscriber from the broadcaster.
-func (b *Broadcaster[T]) Unsubscribe(ch chan<- T) {
-	b.m.Lock()
-	defer b.m.Unlock()
+// handle broadcasts the values carried by the message.
+func (b *Broadcaster[T]) handle(m message[T]) {
+	if m.done != nil {
+		defer close(m.done)
+	}
+
+	if m.receipt != nil {
+		b.tally = &tally{}
+		defer func() {
+			m.receipt <- b.tally.receipt()
+			b.tally = nil
+		}()
+	}
+
+	if m.flush {
+		return
+	}
+
+	if b.ttl > 0 && time.Since(m.at) > b.ttl {
+		// NOTE(njern): The message waited in the buffer for too long.
+		return
+	}
+
+	vs := m.batch
+	if vs == nil {
+		vs = []T{m.v}
+	}
+
+	kept := vs[:0]
+	for _, v := range vs {
+		v, ok := b.intercept(v)
+		if !ok || (b.dedup != nil && !b.dedup(v)) {
+			continue
+		}
+
+		kept = append(kept, v)
+	}
+
+	if len(kept) == 0 || !b.throttle(len(kept)) {
+		return
+	}
+
+	b.broadcastTo(m.tag, kept, true)
+}
 
-	delete(b.subscribers, ch)
+// intercept runs the interceptor set by WithInterceptor on v. It
+// returns false if the value must not be broadcast, including when the
+// interceptor panics.
+func (b *Broadcaster[T]) intercept(v T) (_ T, ok bool) {
+	if b.interceptor == nil {
+		return v, true
+	}
 
 	defer func() {
-		// NOTE(njern): This may happen if the Broadcaster is closed, which also closes
-		// subCh - but the subscriber subsequently calls Unsubscribe.
-		_ = recover()
+		if recover() != nil {
+			ok = false
+		}
 	}()
 
-	close(ch)
+	return b.interceptor(v)
 }
 
-// Close the broadcaster and all subscriber channels.
-func (b *Broadcaster[T]) Close() {
-	close(b.closeCh)
+// drain broadcasts every value left in the input buffer.
+func (b *Broadcaster[T]) drain() {
+	for {
+		select {
+		case m := <-b.valCh:
+			b.handle(m)
+		default:
+			return
+		}
+	}
+}
+
+// resize moves the buffered messages to r.ch and makes it the input
+// buffer, once Resize has locked publishers out. It returns the oldest
+// messages that don't fit, to be broadcast before any others.
+func (b *Broadcaster[T]) resize(r resize[T]) []message[T] {
+	defer close(r.done)
+
+	// NOTE(njern): Keep taking messages until Resize holds pubMu, so
+	// that no publisher holding it for reading waits on a full buffer.
+	var pending []message[T]
+	for waiting := true; waiting; {
+		select {
+		case m := <-b.valCh:
+			pending = append(pending, m)
+		case <-r.locked:
+			waiting = false
+		case <-b.closeCh:
+			return pending
+		}
+	}
+
+	for len(b.valCh) > 0 {
+		pending = append(pending, <-b.valCh)
+	}
+
+	excess := max(len(pending)-cap(r.ch), 0)
+	for _, m := range pending[excess:] {
+		r.ch <- m
+	}
+
+	b.valCh = r.ch
+	return pending[:excess]
+}
+
+// broadcast the values to all subscribers, in order.
+func (b *Broadcaster[T]) broadcast(vs ...T) {
+	b.broadcastTo("", vs, true)
+}
+
+// broadcastTo broadcasts the values, in order, to the subscribers
+// tagged with tag, or to all of them if tag is empty. Unless record is
+// set, the values are not recorded, see prepare.
+func (b *Broadcaster[T]) broadcastTo(tag string, vs []T, record bool) {
+	start := time.Now()
+
+	subs, seq, ok := b.prepare(tag, vs, record)
+	if !ok {
+		return
+	}
+
+	if len(subs) == 0 {
+		if !record {
+			return
+		}
+
+		b.m.RLock()
+		deadLetter := b.deadLetter
+		b.m.RUnlock()
+
+		if deadLetter != nil {
+			for _, v := range vs {
+				deadLetter(v)
+			}
+		}
+
+		return
+	}
+
+	for i, v := range vs {
+		if b.budget > 0 {
+			b.budgetEnd = time.Now().Add(b.budget)
+		}
+
+		if !b.deliver(b.pick(subs), v, seq+uint64(i)) {
+			break
+		}
+	}
+
+	b.latency.record(time.Since(start))
 
+	// NOTE(njern): Run the drop callbacks only once delivery is done,
+	// so that a slow callback doesn't delay any subscriber.
+	drops := b.drops
+	b.drops = nil
+	for _, d := range drops {
+		b.logf("broadcast: dropped %v for %p", d.v, d.ch)
+
+		if b.onDrop != nil {
+			b.onDrop(d.ch, d.v)
+		}
+	}
+
+	evictions := b.evictions
+	b.evictions = nil
+	for _, sub := range evictions {
+		b.unsubscribe(sub.out, ErrEvicted, false)
+
+		if b.onEvict != nil {
+			b.onEvict(sub.out)
+		}
+	}
+}
+
+// prepare records the values about to be broadcast and returns the
+// subscribers to deliver them to, along with the sequence number of the
+// first value. It returns false if the broadcaster was closed.
+//
+// Values for a tag are only delivered to the subscribers carrying it,
+// so they neither get a sequence number nor are replayed. Values that
+// aren't to be recorded, such as heartbeats, aren't either, and they
+// also don't count as published or as the last value, see Latest.
+//
+// NOTE(njern): Both happen under the write lock, so a concurrent
+// Subscribe either receives the values live or as part of its backlog,
+// never both and never neither.
+func (b *Broadcaster[T]) prepare(tag string, vs []T, record bool) ([]*subscriber[T], uint64, bool) {
 	b.m.Lock()
 	defer b.m.Unlock()
-	for ch := range b.subscribers {
-		close(ch)
+
+	if b.IsClosed() {
+		return nil, 0, false
 	}
 
-	b.subscribers = nil
-}
+	if !record {
+		return b.order, 0, true
+	}
 
-// Chan returns the input channel for the broadcaster.
-func (b *Broadcaster[T]) Chan() chan<- T {
-	return b.valCh
+	b.stats.published.Add(uint64(len(vs)))
+
+	if tag != "" {
+		var subs []*subscriber[T]
+		for _, sub := range b.order {
+			if slices.Contains(sub.tags, tag) {
+				subs = append(subs, sub)
+			}
+		}
+
+		for _, v := range vs {
+			b.observer.OnPublish(v)
+		}
+
+		return subs, 0, true
+	}
+
+	first := b.seq + 1
+	for _, v := range vs {
+		b.observer.OnPublish(v)
+
+		b.seq++
+		m := Message[T]{Seq: b.seq, Value: v}
+
+		if b.replay != nil {
+			b.replay.push(m)
+		}
+
+		b.last, b.hasLast = m, true
+	}
+
+	return b.order, first, true
 }
 
-// isClosed checks if the broadcaster has been closed.
-func (b *Broadcaster[T]) isClosed() bool {
-	select {
-	case <-b.closeCh:
+// pick returns the subscribers to deliver a single value to: those that
+// are not in a group and, in turn, one member of each group. It is only
+// called from the run loop, which owns groups.
+func (b *Broadcaster[T]) pick(subs []*subscriber[T]) []*subscriber[T] {
+	members := make(map[string][]*subscriber[T])
+	for _, sub := range subs {
+		if sub.group != "" {
+			members[sub.group] = append(members[sub.group], sub)
+		}
+	}
+
+	if len(members) == 0 {
+		return subs
+	}
+
+	if b.groups == nil {
+		b.groups = make(map[string]uint64)
+	}
+
+	chosen := make(map[string]*subscriber[T], len(members))
+	for group, m := range members {
+		chosen[group] = m[b.groups[group]%uint64(len(m))]
+		b.groups[group]++
+	}
+
+	// NOTE(njern): Keep the priority order that deliver relies on.
+	picked := make([]*subscriber[T], 0, len(subs))
+	for _, sub := range subs {
+		if sub.group == "" || chosen[sub.group] == sub {
+			picked = append(picked, sub)
+		}
+	}
+
+	return picked
+}
+
+// deliver the value to the subscribers. It returns false if the
+// broadcaster was closed.
+//
+// Subscribers are served concurrently so that the timeout applies to
+// each of them independently instead of accumulating across slow ones.
+func (b *Broadcaster[T]) deliver(subs []*subscriber[T], v T, seq uint64) bool {
+	// NOTE(njern): Subscribers with the same priority are served
+	// together, and each priority only once the higher ones are done.
+	for len(subs) > 0 {
+		n := 1
+		for n < len(subs) && subs[n].priority == subs[0].priority {
+			n++
+		}
+
+		if !b.deliverTo(subs[:n], v, seq) {
+			// NOTE(njern): Handle an edge case where the
+			// Broadcaster is closed while broadcasting.
+			return false
+		}
+
+		subs = subs[n:]
+	}
+
+	return true
+}
+
+// deliverTo delivers the value to the given subscribers. It returns
+// false if the broadcaster was closed.
+func (b *Broadcaster[T]) deliverTo(subs []*subscriber[T], v T, seq uint64) bool {
+	if b.sequential {
+		for _, sub := range subs {
+			if !b.send(sub, v, seq) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	var wg sync.WaitGroup
+	if b.jobs != nil {
+		// NOTE(njern): jobs is unbuffered, so every job that was handed
+		// over is run even if the broadcaster closes meanwhile.
+		for _, sub := range subs {
+			wg.Add(1)
+			select {
+			case b.jobs <- job[T]{sub: sub, v: v, seq: seq, wg: &wg}:
+			case <-b.closeCh:
+				wg.Done()
+			}
+		}
+
+		wg.Wait()
+		return !b.IsClosed()
+	}
+
+	wg.Add(len(subs))
+	for _, sub := range subs {
+		go func() {
+			defer wg.Done()
+			b.send(sub, v, seq)
+		}()
+	}
+
+	wg.Wait()
+	return !b.IsClosed()
+}
+
+// send delivers the value to a single subscriber, waiting up to the
+// subscriber's timeout. It returns false if the broadcaster was closed.
+func (b *Broadcaster[T]) send(sub *subscriber[T], v T, seq uint64) (ok bool) {
+	// NOTE(njern): send usually runs on its own goroutine or a worker,
+	// outside the run loop's safely, so it recovers by itself from a
+	// panic in an Observer, the SetClone func, or a send on a channel
+	// the caller closed.
+	defer func() {
+		if r := recover(); r != nil {
+			b.recovered(r)
+			ok = !b.IsClosed()
+		}
+	}()
+
+	if !sub.accepts(v) {
+		return true
+	}
+
+	if clone := b.clone.Load(); clone != nil {
+		v = (*clone)(v)
+	}
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	// NOTE(njern): Sending on a nil channel blocks forever, which would
+	// stall every other subscriber. It should never happen, but if it
+	// does, skip the subscriber rather than freeze the broadcaster.
+	if sub.closed || sub.ch == nil {
+		return true
+	}
+
+	if sub.policy == DropOldest && cap(sub.ch) > 0 {
+		for {
+			select {
+			case sub.ch <- v:
+				b.delivered(sub, v, seq)
+				return true
+			default:
+			}
+
+			// NOTE(njern): The subscriber may read concurrently, in
+			// which case there is nothing to discard and the next
+			// send succeeds.
+			select {
+			case old := <-sub.ch:
+				b.dropped(sub, old)
+			default:
+			}
+		}
+	}
+
+	timeout := b.timeoutFor(sub)
+	if b.budget > 0 && sub.policy != Block {
+		left := time.Until(b.budgetEnd)
+		if left <= 0 {
+			// NOTE(njern): The budget is spent, so only a subscriber
+			// that is ready right now still gets the value.
+			select {
+			case sub.ch <- v:
+				b.delivered(sub, v, seq)
+			default:
+				b.tally.drop()
+				b.dropped(sub, v)
+			}
+
+			return true
+		}
+
+		if timeout == 0 || left < timeout {
+			timeout = left
+		}
+	}
+
+	if timeout == 0 || sub.policy == Block {
+		select {
+		case sub.ch <- v:
+			b.delivered(sub, v, seq)
+		case <-sub.done:
+		case <-b.closeCh:
+			return false
+		}
+
+		return true
+	}
+
+	t := getTimer(timeout)
+	defer putTimer(t)
+
+	select {
+	case sub.ch <- v:
+		b.delivered(sub, v, seq)
+	case <-t.C:
+		// NOTE(njern): The subscriber did not read from the
+		// channel within the timeout, keep going.
+		b.tally.drop()
+		b.missed(sub)
+		b.dropped(sub, v)
+	case <-sub.done:
+	case <-b.closeCh:
+		return false
+	}
+
+	return true
+}
+
+// timeoutFor returns the time to wait for the subscriber to receive a
+// value, or zero to wait indefinitely.
+func (b *Broadcaster[T]) timeoutFor(sub *subscriber[T]) time.Duration {
+	// NOTE(njern): Subscribers that didn't ask for a timeout of their
+	// own follow the broadcaster's, even once it changes.
+	timeout := sub.timeout
+	if timeout == 0 {
+		timeout = b.Timeout()
+	}
+
+	if timeout == 0 && cap(sub.ch) == 0 {
+		timeout = b.minTimeout
+	}
+
+	return timeout
+}
+
+// delivered records that the subscriber received a value. The caller
+// must hold sub.mu.
+func (b *Broadcaster[T]) delivered(sub *subscriber[T], v T, seq uint64) {
+	b.stats.delivered.Add(1)
+	b.tally.deliver()
+	sub.misses = 0
+
+	if sub.trackSeqs {
+		sub.seqs = append(sub.seqs, seq)
+	}
+
+	// NOTE(njern): Call the Observer last, so that the bookkeeping
+	// above is done even if it panics, see send.
+	b.observer.OnDeliver(v)
+}
+
+// missed records that the subscriber timed out, marking it for eviction
+// once it has done so too many times in a row. The caller must hold
+// sub.mu.
+func (b *Broadcaster[T]) missed(sub *subscriber[T]) {
+	threshold := b.evictAfter
+	if sub.policy == Disconnect {
+		threshold = 1
+	}
+
+	sub.misses++
+	if threshold == 0 || sub.misses != threshold {
+		return
+	}
+
+	b.dropsMu.Lock()
+	defer b.dropsMu.Unlock()
+
+	b.evictions = append(b.evictions, sub)
+}
+
+// dropped records that the subscriber missed the value.
+func (b *Broadcaster[T]) dropped(sub *subscriber[T], v T) {
+	b.stats.dropped.Add(1)
+
+	if b.onDrop != nil || b.logger != nil {
+		b.dropsMu.Lock()
+		b.drops = append(b.drops, drop[T]{ch: sub.out, v: v})
+		b.dropsMu.Unlock()
+	}
+
+	// NOTE(njern): Call the Observer last, see delivered.
+	b.observer.OnDrop(v)
+}
+
+// timerPool holds stopped timers so that send does not allocate a new
+// one for every subscriber of every broadcast.
+var timerPool sync.Pool
+
+// getTimer returns a timer that fires after d.
+func getTimer(d time.Duration) *time.Timer {
+	if t, ok := timerPool.Get().(*time.Timer); ok {
+		t.Reset(d)
+		return t
+	}
+
+	return time.NewTimer(d)
+}
+
+// putTimer stops t and returns it to the pool.
+func putTimer(t *time.Timer) {
+	if !t.Stop() {
+		// NOTE(njern): The timer fired but nobody received from it,
+		// drain it so the next user doesn't see a stale tick.
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+
+	timerPool.Put(t)
+}
+
+// Subscribe adds a new subscriber to the broadcaster and returns a channel to listen on.
+//
+// The subscriber receives every value whose broadcast starts after
+// Subscribe returns, and none of those whose broadcast already started.
+// A PublishBatch is broadcast as a whole, so the subscriber receives
+// either all of a batch or none of it. Values replayed by WithReplay or
+// WithSticky come first.
+func (b *Broadcaster[T]) Subscribe(chSize int) (chan T, error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub.out, nil
+}
+
+// SubscribeExisting is like Subscribe but delivers values on ch, which
+// the caller created, for example with a buffer size of its choice.
+// From then on the broadcaster owns ch: it closes ch on Unsubscribe or
+// Close, just like the channels it creates itself. It returns
+// ErrNilChannel if ch is nil and ErrAlreadySubscribed if ch is already
+// subscribed.
+func (b *Broadcaster[T]) SubscribeExisting(ch chan T) error {
+	if ch == nil {
+		return ErrNilChannel
+	}
+
+	_, err := b.subscribeChan(ch, SubscribeOptions[T]{}, subscribeArgs{})
+	return err
+}
+
+// SubscribeChan is like SubscribeExisting except that the caller keeps
+// ownership of ch: the broadcaster stops sending on ch once it is
+// unsubscribed or the broadcaster is closed, but never closes it. It is
+// up to the caller to close ch, if at all, once it is no longer
+// subscribed. To hand ch over to the broadcaster instead, so that it is
+// closed like the channels returned by Subscribe, use SubscribeExisting.
+func (b *Broadcaster[T]) SubscribeChan(ch chan T) error {
+	if ch == nil {
+		return ErrNilChannel
+	}
+
+	_, err := b.subscribeChan(ch, SubscribeOptions[T]{}, subscribeArgs{borrowed: true})
+	return err
+}
+
+// SubscribeWithOptions is like Subscribe but applies opts to the new
+// subscription instead of the broadcaster defaults.
+func (b *Broadcaster[T]) SubscribeWithOptions(chSize int, opts SubscribeOptions[T]) (chan T, error) {
+	sub, err := b.subscribe(chSize, opts, subscribeArgs{})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub.out, nil
+}
+
+// SubscribeWithReason is like Subscribe but also returns a channel that
+// receives exactly one error once the subscription ends, before the
+// values channel is closed: the error passed to CloseWithError, or
+// ErrBroadcasterClosed if the broadcaster was closed without one,
+// ErrEvicted if the subscriber was evicted, or nil if it unsubscribed.
+func (b *Broadcaster[T]) SubscribeWithReason(chSize int) (chan T, <-chan error, error) {
+	errCh := make(chan error, 1)
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{errCh: errCh})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sub.out, errCh, nil
+}
+
+// subscribeArgs holds the settings of a subscriber that are not part of
+// SubscribeOptions.
+type subscribeArgs struct {
+	errCh     chan error // Receives the reason the subscription ended, see SubscribeWithReason
+	trackSeqs bool       // Record sequence numbers, see SubscribeSeq
+	resume    bool       // Replay from fromSeq, see SubscribeFrom
+	fromSeq   uint64
+	live      bool   // Skip the backlog, see SubscribeOnce
+	locked    func() // Called under the lock once added, see SnapshotAndSubscribe
+	internal  bool   // Read by the library itself, see CloseKeepOpen
+	borrowed  bool   // Owned by the caller, see SubscribeChan
+}
+
+// subscribe adds a new subscriber.
+func (b *Broadcaster[T]) subscribe(chSize int, opts SubscribeOptions[T], args subscribeArgs) (*subscriber[T], error) {
+	if chSize < 0 {
+		return nil, ErrInvalidBufferSize
+	}
+
+	return b.subscribeChan(make(chan T, chSize), opts, args)
+}
+
+// subscribeChan adds a new subscriber that receives values on ch.
+func (b *Broadcaster[T]) subscribeChan(ch chan T, opts SubscribeOptions[T], args subscribeArgs) (*subscriber[T], error) {
+	sub, err := b.newSubscriber(ch, opts, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE(njern): Log without holding the lock, see WithLogger.
+	b.logf("broadcast: subscribed %p with buffer size %d", sub.out, cap(ch))
+	if cap(ch) == 0 && b.timeoutFor(sub) == 0 && sub.policy != Block {
+		b.logf("broadcast: %p is unbuffered and has no timeout, so it stalls delivery until it reads, see WithMinTimeout", sub.out)
+	}
+
+	return sub, nil
+}
+
+// newSubscriber creates and registers a subscriber, see subscribe.
+func (b *Broadcaster[T]) newSubscriber(ch chan T, opts SubscribeOptions[T], args subscribeArgs) (*subscriber[T], error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.IsClosed() {
+		return nil, ErrBroadcasterClosed
+	}
+
+	if _, ok := b.subscribers[ch]; ok {
+		return nil, ErrAlreadySubscribed
+	}
+
+	if b.maxSubscribers > 0 && len(b.subscribers) >= b.maxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+
+	b.lastID++
+	sub := &subscriber[T]{
+		id:       b.lastID,
+		out:      ch,
+		ch:       ch,
+		done:     make(chan struct{}),
+		drained:  make(chan struct{}),
+		timeout:  opts.Timeout,
+		policy:   opts.Policy,
+		priority: opts.Priority,
+		filter:   opts.Filter,
+		tags:     slices.Clone(opts.Tags),
+		group:    opts.Group,
+		onClose:  opts.OnClose,
+		internal: args.internal,
+		borrowed: args.borrowed,
+		errCh:    args.errCh,
+
+		trackSeqs: args.trackSeqs,
+	}
+
+	if opts.Backpressure {
+		sub.policy = Block
+	}
+
+	var msgs []Message[T]
+	switch {
+	case args.live:
+		// NOTE(njern): Only values broadcast from now on.
+	case opts.Group != "":
+		// NOTE(njern): Replaying would hand values to this member that
+		// another member of the group already received.
+	case args.resume:
+		var err error
+		if msgs, err = b.replayFrom(args.fromSeq); err != nil {
+			return nil, err
+		}
+	case b.replay != nil:
+		msgs = b.replay.values()
+	case b.sticky && b.hasLast:
+		msgs = []Message[T]{b.last}
+	}
+
+	clone := b.clone.Load()
+	backlog := make([]T, len(msgs))
+	for i, m := range msgs {
+		backlog[i] = m.Value
+		if clone != nil {
+			backlog[i] = (*clone)(m.Value)
+		}
+
+		if args.trackSeqs {
+			sub.seqs = append(sub.seqs, m.Seq)
+		}
+	}
+
+	if len(backlog) > cap(ch)-len(ch) {
+		// NOTE(njern): The backlog doesn't fit in the caller's buffer,
+		// so deliver it from a goroutine while live values queue up
+		// on an intermediate channel.
+		sub.ch = make(chan T, cap(ch))
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			sub.forward(backlog, b.closeCh)
+		}()
+	} else {
+		for _, v := range backlog {
+			ch <- v
+		}
+	}
+
+	b.add(sub)
+	b.stats.subscribes.Add(1)
+	b.observer.OnSubscribe()
+
+	if args.locked != nil {
+		args.locked()
+	}
+
+	return sub, nil
+}
+
+// replayFrom returns the retained values with a sequence number above
+// seq, or ErrDataLost if some of them are no longer retained. The
+// caller must hold the lock.
+func (b *Broadcaster[T]) replayFrom(seq uint64) ([]Message[T], error) {
+	if seq >= b.seq {
+		return nil, nil
+	}
+
+	if b.replay == nil {
+		return nil, ErrDataLost
+	}
+
+	msgs := b.replay.values()
+	i := slices.IndexFunc(msgs, func(m Message[T]) bool { return m.Seq > seq })
+	if i < 0 || msgs[i].Seq != seq+1 {
+		return nil, ErrDataLost
+	}
+
+	return msgs[i:], nil
+}
+
+// add registers the subscriber. The caller must hold the write lock.
+func (b *Broadcaster[T]) add(sub *subscriber[T]) {
+	if _, ok := b.subscribers[sub.out]; ok {
+		// NOTE(njern): Two subscribers sharing a channel would both
+		// close it, see newSubscriber.
+		panic("broadcast: channel subscribed twice")
+	}
+
+	i := len(b.order)
+	for i > 0 && b.order[i-1].priority < sub.priority {
+		i--
+	}
+
+	// NOTE(njern): Never modify order in place, broadcast may still be
+	// delivering to a previous version of it.
+	b.order = slices.Insert(slices.Clip(b.order), i, sub)
+	b.subscribers[sub.out] = sub
+
+	// NOTE(njern): Wake up everyone in WaitForSubscribers so that they
+	// can check the new count.
+	if b.joined != nil {
+		close(b.joined)
+		b.joined = nil
+	}
+}
+
+// remove unregisters the subscriber. The caller must hold the write lock.
+func (b *Broadcaster[T]) remove(sub *subscriber[T]) {
+	b.order = slices.DeleteFunc(slices.Clone(b.order), func(s *subscriber[T]) bool {
+		return s == sub
+	})
+	delete(b.subscribers, sub.out)
+}
+
+// SubscribeUnbuffered is like Subscribe(0). A value is only delivered
+// to an unbuffered subscriber once it is ready to receive it, so with a
+// zero timeout the broadcaster waits for it to read every value. Other
+// subscribers still receive the current value in the meantime, but none
+// of them receive the next one until this subscriber has read.
+func (b *Broadcaster[T]) SubscribeUnbuffered() (chan T, error) {
+	return b.Subscribe(0)
+}
+
+// SubscribeConflated adds a subscriber that only ever holds the most
+// recent value, so a slow reader always sees the freshest one.
+func (b *Broadcaster[T]) SubscribeConflated() (<-chan T, error) {
+	return b.SubscribeWithOptions(1, SubscribeOptions[T]{Policy: DropOldest})
+}
+
+// SubscribeFilter adds a subscriber that only receives the values
+// pred returns true for. pred is called once per value, during
+// delivery, and should return quickly.
+func (b *Broadcaster[T]) SubscribeFilter(chSize int, pred func(T) bool) (<-chan T, error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{Filter: pred}, subscribeArgs{})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub.out, nil
+}
+
+// SubscribeContext is like Subscribe but automatically unsubscribes
+// when ctx is done, closing the returned channel.
+func (b *Broadcaster[T]) SubscribeContext(ctx context.Context, chSize int) (<-chan T, error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.Unsubscribe(sub.out)
+		case <-sub.done:
+			// NOTE(njern): The subscription already ended, either by
+			// Unsubscribe, eviction or Close.
+		}
+	}()
+
+	return sub.out, nil
+}
+
+// SubscribeOnce waits for the next broadcast value and returns it. It
+// returns ErrBroadcasterClosed if the broadcaster is closed first, or
+// ctx.Err() if ctx is done first. Values replayed to new subscribers are
+// skipped.
+func (b *Broadcaster[T]) SubscribeOnce(ctx context.Context) (T, error) {
+	return b.Await(ctx, nil)
+}
+
+// Await is like SubscribeOnce but waits for the next broadcast value
+// for which pred returns true. A nil pred matches every value, and a
+// pred that panics is treated as returning false.
+func (b *Broadcaster[T]) Await(ctx context.Context, pred func(T) bool) (T, error) {
+	var zero T
+
+	sub, err := b.subscribe(1, SubscribeOptions[T]{Filter: pred}, subscribeArgs{live: true, internal: true})
+	if err != nil {
+		return zero, err
+	}
+	defer b.Unsubscribe(sub.out)
+
+	select {
+	case v, ok := <-sub.out:
+		if !ok {
+			return zero, ErrBroadcasterClosed
+		}
+
+		return v, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// Latest returns the most recently broadcast value and true, or false if
+// nothing has been broadcast yet. Values published with PublishTo are
+// not included.
+func (b *Broadcaster[T]) Latest() (T, bool) {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	return b.last.Value, b.hasLast
+}
+
+// SnapshotAndSubscribe returns the most recently broadcast value, if
+// any, along with a channel that receives every value broadcast after
+// it, which is passed to Unsubscribe like the one returned by Subscribe.
+// Both are taken at once, so there is no gap between reading the
+// current value and subscribing in which an update could be missed.
+func (b *Broadcaster[T]) SnapshotAndSubscribe(chSize int) (last T, hasLast bool, ch chan T, err error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{
+		live: true,
+		locked: func() {
+			last, hasLast = b.last.Value, b.hasLast
+		},
+	})
+	if err != nil {
+		return last, false, nil, err
+	}
+
+	return last, hasLast, sub.out, nil
+}
+
+// SubscribeFor is like Subscribe but automatically unsubscribes once d
+// has elapsed, closing the returned channel.
+func (b *Broadcaster[T]) SubscribeFor(chSize int, d time.Duration) (<-chan T, error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		t := time.NewTimer(d)
+		defer t.Stop()
+
+		select {
+		case <-t.C:
+			b.Unsubscribe(sub.out)
+		case <-sub.done:
+			// NOTE(njern): The subscription already ended, either by
+			// Unsubscribe, eviction or Close.
+		}
+	}()
+
+	return sub.out, nil
+}
+
+// SubscribeFunc adds a subscriber that calls fn, on its own goroutine,
+// for every value received. The returned cancel func unsubscribes and
+// stops calling fn; the goroutine also exits when the broadcaster is
+// closed.
+func (b *Broadcaster[T]) SubscribeFunc(chSize int, fn func(T)) (cancel func(), err error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{internal: true})
+	if err != nil {
+		return nil, err
+	}
+	ch := sub.out
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case v, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				select {
+				case <-done:
+					// NOTE(njern): Don't call fn with values that were
+					// still buffered when cancel was called.
+					return
+				default:
+				}
+
+				fn(v)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			b.Unsubscribe(ch)
+		})
+	}, nil
+}
+
+// FanOut subscribes n times with a buffer of size chSize and returns
+// the channels along with a cancel func that unsubscribes all of them.
+// Calling cancel more than once is a no-op. If any of the subscriptions
+// fails, those already made are undone and the error is returned. A
+// negative n returns ErrInvalidCount.
+func (b *Broadcaster[T]) FanOut(n, chSize int) ([]<-chan T, func(), error) {
+	if n < 0 {
+		return nil, nil, ErrInvalidCount
+	}
+
+	subs := make([]*subscriber[T], 0, n)
+	cancel := func() {
+		for _, sub := range subs {
+			b.Unsubscribe(sub.out)
+		}
+	}
+
+	chs := make([]<-chan T, 0, n)
+	for range n {
+		sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{})
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+
+		subs = append(subs, sub)
+		chs = append(chs, sub.out)
+	}
+
+	return chs, cancel, nil
+}
+
+// All returns an iterator over broadcast values. Each iteration
+// subscribes with an unbuffered channel when it starts and unsubscribes
+// when the loop ends, either early or because the broadcaster closed.
+func (b *Broadcaster[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		sub, err := b.subscribe(0, SubscribeOptions[T]{}, subscribeArgs{internal: true})
+		if err != nil {
+			return
+		}
+
+		defer b.Unsubscribe(sub.out)
+
+		for v := range sub.out {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Unsubscribe removes a subscriber from the broadcaster and closes its
+// channel. It is a no-op if ch is not subscribed, so it is safe to call
+// more than once and never closes a channel the broadcaster didn't
+// create.
+func (b *Broadcaster[T]) Unsubscribe(ch chan<- T) {
+	b.unsubscribe(ch, nil, false)
+}
+
+// UnsubscribeChecked is like Unsubscribe but returns ErrNotSubscribed,
+// and leaves ch alone, if ch is not subscribed to this broadcaster, for
+// example because it belongs to another one or was already removed.
+func (b *Broadcaster[T]) UnsubscribeChecked(ch chan<- T) error {
+	if b.unsubscribe(ch, nil, false) == nil {
+		return ErrNotSubscribed
+	}
+
+	return nil
+}
+
+// UnsubscribeDrain is like Unsubscribe but values already on their way
+// to ch, such as a replayed backlog that didn't fit in its buffer, are
+// still delivered before ch is closed. Values buffered in ch itself can
+// always be read after it is closed. The returned channel is closed
+// once ch has been closed, or for a channel passed to SubscribeChan once
+// nothing more is sent on it, and right away if ch is not subscribed.
+func (b *Broadcaster[T]) UnsubscribeDrain(ch chan<- T) <-chan struct{} {
+	sub := b.unsubscribe(ch, nil, true)
+	if sub == nil {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+
+	return sub.drained
+}
+
+// unsubscribe removes a subscriber for the given reason and returns it,
+// or nil if ch is not subscribed. If drain is set, values on their way
+// to ch are still delivered, see UnsubscribeDrain.
+func (b *Broadcaster[T]) unsubscribe(ch chan<- T, reason error, drain bool) *subscriber[T] {
+	b.m.Lock()
+	sub, ok := b.subscribers[ch]
+	if ok {
+		b.remove(sub)
+		sub.drain = drain
+		sub.close(reason)
+		b.stats.unsubscribes.Add(1)
+		b.observer.OnUnsubscribe()
+	}
+	b.m.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	if !sub.forwarded() && sub.closesOut() {
+		sub.ended()
+	}
+
+	b.logf("broadcast: unsubscribed %p: %v", ch, reason)
+	return sub
+}
+
+// Close the broadcaster and all subscriber channels. Calling Close
+// more than once is a no-op.
+func (b *Broadcaster[T]) Close() {
+	b.CloseWithError(nil)
+}
+
+// CloseWithError is like Close but records err as the reason, which is
+// then returned by Err and reported to subscribers that asked for it.
+// Only the first call to Close or CloseWithError has any effect.
+func (b *Broadcaster[T]) CloseWithError(err error) {
+	b.closeOnce.Do(func() {
+		b.close(err, false)
+	})
+}
+
+// CloseKeepOpen closes the broadcaster like Close, except that the
+// subscriber channels are left open rather than closed, so subscribers
+// keep waiting for values that never come instead of seeing their
+// channel close. This is for subscribers whose lifecycle is managed
+// separately. OnClose callbacks are not called, and helpers that read
+// their own subscription, such as SubscribeFunc or Map, still close
+// theirs so that they can stop. Only the first call to
+// Close, CloseWithError or CloseKeepOpen has any effect.
+func (b *Broadcaster[T]) CloseKeepOpen() {
+	b.closeOnce.Do(func() {
+		b.close(nil, true)
+	})
+}
+
+// close closes the broadcaster, see CloseWithError.
+func (b *Broadcaster[T]) close(err error, keepOpen bool) {
+	b.stopOnce.Do(func() {
+		close(b.stopCh)
+	})
+
+	b.m.Lock()
+	b.err = err
+	b.m.Unlock()
+
+	// NOTE(njern): Release the run loop, which may be stuck on a slow
+	// subscriber, before waiting for in-flight publishers.
+	close(b.closeCh)
+	b.stop()
+
+	reason := err
+	if reason == nil {
+		reason = ErrBroadcasterClosed
+	}
+
+	b.m.Lock()
+	for _, sub := range b.subscribers {
+		// NOTE(njern): Channels read by the library itself are always
+		// closed, so that the goroutines reading them exit.
+		sub.keepOpen = keepOpen && !sub.internal
+		sub.close(reason)
+		b.stats.unsubscribes.Add(1)
+		b.observer.OnUnsubscribe()
+	}
+
+	subs := b.order
+	b.subscribers = nil
+	b.order = nil
+	b.m.Unlock()
+
+	for _, sub := range subs {
+		if !sub.forwarded() && sub.closesOut() {
+			sub.ended()
+		}
+	}
+
+	b.logf("broadcast: closed: %v", reason)
+	close(b.doneCh)
+}
+
+// Err returns the error the broadcaster was closed with, or nil if it is
+// still open or was closed by Close.
+func (b *Broadcaster[T]) Err() error {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	return b.err
+}
+
+// WaitClosed blocks until the broadcaster has been closed and its
+// internal goroutines have exited, at which point every subscriber
+// channel is closed. It must not be called from a callback run by the
+// broadcaster, such as the one set by WithOnDrop.
+func (b *Broadcaster[T]) WaitClosed() {
+	<-b.doneCh
+	b.wg.Wait()
+}
+
+// CloseGracefully stops accepting new values, waits for the ones
+// already buffered to be broadcast and then closes the broadcaster like
+// Close. If ctx is done first, the broadcaster is closed immediately
+// and ctx.Err() is returned.
+func (b *Broadcaster[T]) CloseGracefully(ctx context.Context) error {
+	b.stop()
+
+	done := make(chan struct{})
+	select {
+	case b.drainCh <- done:
+	case <-ctx.Done():
+		b.Close()
+		return ctx.Err()
+	case <-b.closeCh:
+		// NOTE(njern): Already closed, there is nothing to drain.
+		return nil
+	}
+
+	select {
+	case <-done:
+		b.Close()
+		return nil
+	case <-ctx.Done():
+		b.Close()
+		return ctx.Err()
+	}
+}
+
+// stop rejects further values and waits for in-flight publishers.
+func (b *Broadcaster[T]) stop() {
+	b.stopOnce.Do(func() {
+		close(b.stopCh)
+	})
+
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+}
+
+// SetDeadLetter makes the broadcaster call fn with every value it
+// broadcasts while nobody is subscribed to receive it, instead of
+// silently discarding the value. fn is called on the broadcast
+// goroutine, so it should return quickly. A nil fn removes it.
+func (b *Broadcaster[T]) SetDeadLetter(fn func(v T)) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.deadLetter = fn
+}
+
+// Timeout returns the timeout applied to subscribers that don't set
+// their own, see SubscribeOptions.
+func (b *Broadcaster[T]) Timeout() time.Duration {
+	return time.Duration(b.timeout.Load())
+}
+
+// SetTimeout changes the timeout applied to subscribers that don't set
+// their own, see SubscribeOptions, including existing ones. It only
+// affects values delivered from now on: a delivery that is already
+// waiting keeps the timeout it started with.
+func (b *Broadcaster[T]) SetTimeout(d time.Duration) {
+	b.timeout.Store(int64(d))
+}
+
+// SetClone makes the broadcaster deliver fn(v) instead of v to each
+// subscriber, calling fn once per subscriber. This allows sharing a
+// pointer or other reference type safely by deep-copying it, at the
+// cost of the copy. Values replayed to new subscribers are cloned too.
+// By default every subscriber receives the same v. A nil fn removes it.
+func (b *Broadcaster[T]) SetClone(fn func(v T) T) {
+	if fn == nil {
+		b.clone.Store(nil)
+		return
+	}
+
+	b.clone.Store(&fn)
+}
+
+// SubscriberCount returns the number of active subscribers.
+func (b *Broadcaster[T]) SubscriberCount() int {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	return len(b.subscribers)
+}
+
+// WaitForSubscribers blocks until the broadcaster has at least n
+// subscribers. It returns ctx.Err() if ctx is done first, or
+// ErrBroadcasterClosed if the broadcaster is closed.
+func (b *Broadcaster[T]) WaitForSubscribers(ctx context.Context, n int) error {
+	for {
+		b.m.Lock()
+		if b.IsClosed() {
+			b.m.Unlock()
+			return ErrBroadcasterClosed
+		}
+
+		if len(b.subscribers) >= n {
+			b.m.Unlock()
+			return nil
+		}
+
+		if b.joined == nil {
+			b.joined = make(chan struct{})
+		}
+		joined := b.joined
+		b.m.Unlock()
+
+		select {
+		case <-joined:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.closeCh:
+			return ErrBroadcasterClosed
+		}
+	}
+}
+
+// A SubscriptionInfo describes an active subscription.
+type SubscriptionInfo struct {
+	ID      uint64   // Unique for the lifetime of the broadcaster
+	Cap     int      // Size of the subscriber's buffer
+	Backlog int      // Number of values waiting to be read
+	Tags    []string // See SubscribeOptions
+}
+
+// Subscriptions returns a snapshot of the active subscriptions, in the
+// order they are served.
+func (b *Broadcaster[T]) Subscriptions() []SubscriptionInfo {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	infos := make([]SubscriptionInfo, 0, len(b.order))
+	for _, sub := range b.order {
+		infos = append(infos, SubscriptionInfo{
+			ID:      sub.id,
+			Cap:     cap(sub.out),
+			Backlog: sub.backlog(),
+			Tags:    slices.Clone(sub.tags),
+		})
+	}
+
+	return infos
+}
+
+// SubscriberBacklogs returns the number of values waiting to be read
+// by each subscriber, keyed by the subscriber's channel.
+func (b *Broadcaster[T]) SubscriberBacklogs() map[<-chan T]int {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	backlogs := make(map[<-chan T]int, len(b.subscribers))
+	for _, sub := range b.subscribers {
+		backlogs[sub.out] = sub.backlog()
+	}
+
+	return backlogs
+}
+
+// SlowestSubscriber returns the ID and backlog of the subscriber with
+// the most values waiting to be read, or ok false if there are no
+// subscribers. The ID is the decimal form of SubscriptionInfo.ID and can
+// be passed to DropSubscriber.
+func (b *Broadcaster[T]) SlowestSubscriber() (id string, backlog int, ok bool) {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	var slowest *subscriber[T]
+	for _, sub := range b.order {
+		if n := sub.backlog(); slowest == nil || n > backlog {
+			slowest, backlog = sub, n
+		}
+	}
+
+	if slowest == nil {
+		return "", 0, false
+	}
+
+	return strconv.FormatUint(slowest.id, 10), backlog, true
+}
+
+// DropSubscriber forcibly unsubscribes the subscriber with the given
+// ID, see SlowestSubscriber, and reports ErrEvicted to it. It is a no-op
+// if no subscriber has that ID.
+func (b *Broadcaster[T]) DropSubscriber(id string) {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return
+	}
+
+	b.m.RLock()
+	var ch chan T
+	for _, sub := range b.order {
+		if sub.id == n {
+			ch = sub.out
+			break
+		}
+	}
+	b.m.RUnlock()
+
+	if ch != nil {
+		b.unsubscribe(ch, ErrEvicted, false)
+	}
+}
+
+// Publish sends a value to all subscribers. It blocks while the input
+// buffer is full, unless another policy was set with WithInputPolicy,
+// and returns ErrBroadcasterClosed if the broadcaster has been closed.
+func (b *Broadcaster[T]) Publish(v T) error {
+	return b.PublishContext(context.Background(), v)
+}
+
+// PublishContext is like Publish but gives up and returns ctx.Err()
+// if ctx is done before the value fits in the input buffer.
+func (b *Broadcaster[T]) PublishContext(ctx context.Context, v T) error {
+	return b.enqueue(ctx, message[T]{v: v})
+}
+
+// PublishAndWait is like PublishContext but also waits until v has been
+// broadcast, that is until every subscriber has either received it or
+// missed it according to its policy. It returns ctx.Err() if ctx is
+// done first, or ErrBroadcasterClosed if the broadcaster is closed.
+func (b *Broadcaster[T]) PublishAndWait(ctx context.Context, v T) error {
+	done := make(chan struct{})
+	if err := b.enqueue(ctx, message[T]{v: v, done: done}); err != nil {
+		return err
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.closeCh:
+		return ErrBroadcasterClosed
+	}
+}
+
+// PublishBatch sends the values to all subscribers, in order, as a
+// single unit. It blocks while the input buffer is full and returns
+// ErrBroadcasterClosed if the broadcaster has been closed.
+func (b *Broadcaster[T]) PublishBatch(vs []T) error {
+	if len(vs) == 0 {
+		return nil
+	}
+
+	// NOTE(njern): Copy the values so that the caller may reuse vs
+	// while the batch is still waiting in the buffer.
+	return b.enqueue(context.Background(), message[T]{batch: slices.Clone(vs)})
+}
+
+// PublishAtomic is like PublishBatch. The values reach each subscriber
+// back to back, in order, with no value from any other publisher in
+// between, although a subscriber may still miss some of them according
+// to its policy.
+func (b *Broadcaster[T]) PublishAtomic(vs ...T) error {
+	// NOTE(njern): The run loop broadcasts a batch as a whole before it
+	// picks up the next message, which is all it takes.
+	return b.PublishBatch(vs)
+}
+
+// enqueue adds the message to the input buffer.
+func (b *Broadcaster[T]) enqueue(ctx context.Context, m message[T]) error {
+	b.pubMu.RLock()
+	defer b.pubMu.RUnlock()
+
+	if b.isStopped() {
+		return ErrBroadcasterClosed
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if b.ttl > 0 {
+		m.at = time.Now()
+	}
+
+	// NOTE(njern): Flush markers are never dropped, or Flush would wait
+	// for them forever.
+	if !m.flush && cap(b.valCh) > 0 {
+		switch b.inputPolicy {
+		case DropNewest:
+			select {
+			case b.valCh <- m:
+			default:
+				m.discard()
+			}
+
+			return nil
+		case DropOldest:
+			b.evictFor(m)
+			return nil
+		}
+	}
+
+	select {
+	case b.valCh <- m:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.stopCh:
+		return ErrBroadcasterClosed
+	}
+}
+
+// evictFor adds the message to the input buffer, discarding the oldest
+// buffered messages to make room. Flush markers are never discarded but
+// put back behind the others, and once as many markers as the buffer
+// holds have been put back, the message itself is discarded instead.
+// Publishers must hold pubMu for reading.
+func (b *Broadcaster[T]) evictFor(m message[T]) {
+	markers := 0
+	for {
+		select {
+		case b.valCh <- m:
+			return
+		default:
+		}
+
+		// NOTE(njern): The run loop may read concurrently, in which
+		// case there is nothing to discard and the next send succeeds.
+		select {
+		case old := <-b.valCh:
+			if !old.flush {
+				old.discard()
+				continue
+			}
+
+			// NOTE(njern): Discarding a marker would make Flush return
+			// before the values ahead of it were broadcast. Putting it
+			// back only waits if another publisher took its place.
+			select {
+			case b.valCh <- old:
+			case <-b.closeCh:
+				return
+			}
+
+			markers++
+			if markers >= cap(b.valCh) {
+				m.discard()
+				return
+			}
+		default:
+		}
+	}
+}
+
+// PublishTo is like Publish but only sends the value to the subscribers
+// tagged with tag, see SubscribeOptions. The value is not delivered at
+// all if no subscriber carries the tag, and it is never replayed to new
+// subscribers. An empty tag sends the value to all subscribers.
+func (b *Broadcaster[T]) PublishTo(tag string, v T) error {
+	return b.enqueue(context.Background(), message[T]{v: v, tag: tag})
+}
+
+// Flush blocks until every value published before the call has been
+// broadcast, or until ctx is done.
+func (b *Broadcaster[T]) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	if err := b.enqueue(ctx, message[T]{flush: true, done: done}); err != nil {
+		return err
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.closeCh:
+		return ErrBroadcasterClosed
+	}
+}
+
+// TryPublish sends a value to all subscribers without blocking. It
+// returns false if the input buffer is full or the broadcaster has
+// been closed.
+func (b *Broadcaster[T]) TryPublish(v T) bool {
+	b.pubMu.RLock()
+	defer b.pubMu.RUnlock()
+
+	if b.isStopped() {
+		return false
+	}
+
+	m := message[T]{v: v}
+	if b.ttl > 0 {
+		m.at = time.Now()
+	}
+
+	select {
+	case b.valCh <- m:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pause stops broadcasting until Resume is called. Values published in
+// the meantime are kept in the input buffer, and publishers block once
+// it is full, as they would with a slow subscriber. The buffered values
+// are still broadcast by CloseGracefully, and by Resize if they no
+// longer fit. Pause is a no-op once the broadcaster is closed.
+func (b *Broadcaster[T]) Pause() {
+	b.setPaused(true)
+}
+
+// Resume broadcasts the values buffered since Pause, in order, and
+// then carries on as usual.
+func (b *Broadcaster[T]) Resume() {
+	b.setPaused(false)
+}
+
+// setPaused hands the pause state to the run loop.
+func (b *Broadcaster[T]) setPaused(paused bool) {
+	select {
+	case b.pauseCh <- paused:
+	case <-b.closeCh:
+	}
+}
+
+// Resize changes the size of the input buffer to n, keeping the values
+// already buffered. It waits for in-flight publishers and returns
+// ErrBroadcasterClosed if the broadcaster has been closed.
+func (b *Broadcaster[T]) Resize(n int) error {
+	if n < 0 {
+		return ErrInvalidBufferSize
+	}
+
+	if b.isStopped() {
+		return ErrBroadcasterClosed
+	}
+
+	r := resize[T]{
+		ch:     make(chan message[T], n),
+		locked: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	// NOTE(njern): Only lock publishers out once the run loop has taken
+	// the resize, since it may be stuck on a slow subscriber until then.
+	select {
+	case b.resizeCh <- r:
+	case <-b.closeCh:
+		return ErrBroadcasterClosed
+	}
+
+	// NOTE(njern): Holding pubMu for writing keeps publishers away from
+	// valCh while the run loop swaps it.
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+
+	close(r.locked)
+	select {
+	case <-r.done:
+	case <-b.closeCh:
+		return ErrBroadcasterClosed
+	}
+
+	return nil
+}
+
+// BufferLen returns the number of messages waiting in the input
+// buffer. A batch sent with PublishBatch counts as one message.
+func (b *Broadcaster[T]) BufferLen() int {
+	b.pubMu.RLock()
+	defer b.pubMu.RUnlock()
+
+	return len(b.valCh)
+}
+
+// BufferCap returns the capacity of the input buffer.
+func (b *Broadcaster[T]) BufferCap() int {
+	b.pubMu.RLock()
+	defer b.pubMu.RUnlock()
+
+	return cap(b.valCh)
+}
+
+// Chan returns the input channel for the broadcaster. It is buffered
+// like the input buffer was when the broadcaster was created.
+//
+// Values sent on Chan are published in the order they were sent, but
+// by a separate goroutine, so they are not ordered with values passed
+// to Publish, and a Flush may return before they are broadcast. Values
+// still waiting on Chan once the broadcaster stops accepting values are
+// discarded, and a send on Chan after that may block forever. Prefer
+// Publish, which returns ErrBroadcasterClosed instead.
+func (b *Broadcaster[T]) Chan() chan<- T {
+	return b.inCh
+}
+
+// forwardChan publishes the values sent on Chan until the broadcaster
+// stops accepting values.
+func (b *Broadcaster[T]) forwardChan() {
+	for {
+		select {
+		case v := <-b.inCh:
+			if b.Publish(v) != nil {
+				return
+			}
+		case <-b.stopCh:
+			return
+		}
+	}
+}
+
+// Ingest publishes the values received from src until src is closed,
+// the broadcaster stops accepting values or the returned stop function
+// is called. Once stop returns, no further values from src are
+// published.
+func (b *Broadcaster[T]) Ingest(src <-chan T) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case v, ok := <-src:
+				if !ok {
+					return
+				}
+
+				if b.PublishContext(ctx, v) != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-b.stopCh:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Done returns a channel that is closed when the broadcaster is closed.
+func (b *Broadcaster[T]) Done() <-chan struct{} {
+	return b.closeCh
+}
+
+// IsClosed reports whether the broadcaster has been closed.
+func (b *Broadcaster[T]) IsClosed() bool {
+	select {
+	case <-b.closeCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// isStopped checks if the broadcaster has stopped accepting values.
+func (b *Broadcaster[T]) isStopped() bool {
+	select {
+	case <-b.stopCh:
 		return true
 	default:
 		return false
@@ -1,7 +1,13 @@
 package broadcast
 
 import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -49,6 +55,67 @@ func TestBroadcastBasic(t *testing.T) {
 	}
 }
 
+func TestChanOrder(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	if n := cap(b.Chan()); n != 10 {
+		t.Errorf("Expected Chan to buffer 10 values, got %d", n)
+	}
+
+	ch, err := b.Subscribe(100)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := range 100 {
+		b.Chan() <- i
+	}
+
+	for want := range 100 {
+		select {
+		case v := <-ch:
+			if v != want {
+				t.Fatalf("Expected %d, got %d", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", want)
+		}
+	}
+}
+
+func TestChanWithTTL(t *testing.T) {
+	b := New(1, 0, WithTTL[int](time.Hour))
+	defer b.Close()
+
+	ch, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	b.Chan() <- 1
+
+	select {
+	case v := <-ch:
+		if v != 1 {
+			t.Errorf("Expected 1, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for 1")
+	}
+}
+
+func TestChanAfterClose(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	b := New[int](10, 0)
+	b.Chan() <- 1
+	b.Close()
+	b.WaitClosed()
+
+	waitForGoroutines(t, baseline)
+}
+
 func TestBroadcastChannelWithTimeout(t *testing.T) {
 	b := New[int](10, 50*time.Millisecond)
 	defer b.Close()
@@ -119,6 +186,33 @@ func TestBroadcastSubscriberClosesChannel(t *testing.T) {
 	}
 }
 
+func TestUnsubscribeClosedChannel(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		close func(b *Broadcaster[int], ch chan int)
+	}{
+		{"Unsubscribe", func(b *Broadcaster[int], ch chan int) { b.Unsubscribe(ch) }},
+		{"Close", func(b *Broadcaster[int], ch chan int) { b.Close() }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := New[int](10, 0)
+			defer b.Close()
+
+			ch, err := b.Subscribe(10)
+			if err != nil {
+				t.Fatalf("Failed to subscribe: %v", err)
+			}
+
+			close(ch)
+			tc.close(b, ch)
+
+			if got := b.SubscriberCount(); got != 0 {
+				t.Errorf("Expected 0 subscribers, got %d", got)
+			}
+		})
+	}
+}
+
 func TestSubscribeSubscriberClosed(t *testing.T) {
 	b := New[int](10, 0)
 	b.Close()
@@ -139,7 +233,10 @@ func TestConcurrentSubscriptions(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(subCount)
 
-	var subs []chan int
+	var (
+		subsMu sync.Mutex
+		subs   []chan int
+	)
 
 	for i := 0; i < subCount; i++ {
 		go func() {
@@ -148,7 +245,9 @@ func TestConcurrentSubscriptions(t *testing.T) {
 				t.Errorf("Failed to subscribe: %v", err)
 			}
 
+			subsMu.Lock()
 			subs = append(subs, subCh)
+			subsMu.Unlock()
 
 			wg.Done()
 		}()
@@ -172,3 +271,4092 @@ func TestConcurrentSubscriptions(t *testing.T) {
 		t.Errorf("Expected %d subscribers, got %d", subCount, len(b.subscribers))
 	}
 }
+
+func TestConcurrentSubscribePublishUnsubscribe(t *testing.T) {
+	b := New[int](10, time.Millisecond)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	wg.Add(2 * workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				subCh, err := b.Subscribe(1)
+				if err != nil {
+					t.Errorf("Failed to subscribe: %v", err)
+					return
+				}
+
+				select {
+				case <-subCh:
+				case <-time.After(time.Millisecond):
+				}
+
+				b.Unsubscribe(subCh)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				if err := b.Publish(j); err != nil {
+					t.Errorf("Failed to publish: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	b.Close()
+
+	if got := b.SubscriberCount(); got != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", got)
+	}
+}
+
+func TestSubscriberCount(t *testing.T) {
+	b := New[int](10, 0)
+
+	const subCount = 5
+	var subs []chan int
+	for i := 0; i < subCount; i++ {
+		subCh, err := b.Subscribe(1)
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		subs = append(subs, subCh)
+	}
+
+	if got := b.SubscriberCount(); got != subCount {
+		t.Errorf("Expected %d subscribers, got %d", subCount, got)
+	}
+
+	b.Unsubscribe(subs[0])
+	b.Unsubscribe(subs[1])
+
+	if got := b.SubscriberCount(); got != subCount-2 {
+		t.Errorf("Expected %d subscribers, got %d", subCount-2, got)
+	}
+
+	b.Close()
+
+	if got := b.SubscriberCount(); got != 0 {
+		t.Errorf("Expected 0 subscribers after Close, got %d", got)
+	}
+}
+
+func TestIsClosed(t *testing.T) {
+	b := New[int](10, 0)
+
+	if b.IsClosed() {
+		t.Errorf("Expected new broadcaster to be open")
+	}
+
+	b.Close()
+
+	if !b.IsClosed() {
+		t.Errorf("Expected broadcaster to be closed after Close")
+	}
+}
+
+func TestPublish(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	subCh, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case val := <-subCh:
+		if val != 1 {
+			t.Errorf("Expected to receive 1, got %d", val)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected to receive a message but did not")
+	}
+}
+
+func TestPublishAfterClose(t *testing.T) {
+	b := New[int](10, 0)
+	b.Close()
+
+	if err := b.Publish(1); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+}
+
+func TestTryPublish(t *testing.T) {
+	b := New[int](1, 0)
+	defer b.Close()
+
+	// NOTE(njern): The unbuffered subscriber never reads, so the run loop
+	// blocks on the first value and the second one fills the buffer.
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Publish(2); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if b.TryPublish(3) {
+		t.Errorf("Expected TryPublish to fail on a full buffer")
+	}
+}
+
+func TestTryPublishAfterClose(t *testing.T) {
+	b := New[int](1, 0)
+	b.Close()
+
+	if b.TryPublish(1) {
+		t.Errorf("Expected TryPublish to fail on a closed broadcaster")
+	}
+}
+
+func TestPublishContextCancelled(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := b.PublishContext(ctx, 1); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestPublishContextFullBuffer(t *testing.T) {
+	b := New[int](1, 0)
+	defer b.Close()
+
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): Block the run loop on the first value and fill the
+	// buffer with the second.
+	for i := 0; i < 2; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := b.PublishContext(ctx, 3); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestPublishContextAfterClose(t *testing.T) {
+	b := New[int](10, 0)
+	b.Close()
+
+	if err := b.PublishContext(context.Background(), 1); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+}
+
+func TestSubscribeContext(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	subCh, err := b.SubscribeContext(ctx, 10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if got := b.SubscriberCount(); got != 1 {
+		t.Errorf("Expected 1 subscriber, got %d", got)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-subCh:
+		if ok {
+			t.Errorf("Expected subscriber channel to be closed but it was still open")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected subscriber channel to be closed after cancel")
+	}
+
+	if got := b.SubscriberCount(); got != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", got)
+	}
+}
+
+func TestSubscribeContextBroadcasterClosed(t *testing.T) {
+	b := New[int](10, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subCh, err := b.SubscribeContext(ctx, 10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	b.Close()
+
+	select {
+	case _, ok := <-subCh:
+		if ok {
+			t.Errorf("Expected subscriber channel to be closed but it was still open")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected subscriber channel to be closed after Close")
+	}
+}
+
+func TestSubscribeWithOptionsTimeout(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	fastCh, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	slowCh, err := b.SubscribeWithOptions(0, SubscribeOptions[int]{Timeout: 500 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// NOTE(njern): Neither subscriber is reading yet. The one using
+	// the broadcaster default gives up long before the other one.
+	time.Sleep(100 * time.Millisecond)
+
+	select {
+	case val := <-slowCh:
+		if val != 1 {
+			t.Errorf("Expected to receive 1, got %d", val)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected subscriber with a longer timeout to receive the message")
+	}
+
+	select {
+	case val := <-fastCh:
+		t.Errorf("Expected subscriber with the default timeout to miss the message, got %d", val)
+	default:
+	}
+}
+
+func TestSubscribeWithOptionsDefaultTimeout(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	// NOTE(njern): Neither sets a Timeout, so a stuck one must not hold
+	// up the broadcaster for longer than its timeout.
+	for _, opts := range []SubscribeOptions[int]{
+		{Group: "workers"},
+		{Priority: 1, Tags: []string{"a"}},
+	} {
+		if _, err := b.SubscribeWithOptions(0, opts); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := b.Flush(ctx); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if n := b.Stats().Dropped; n != 6 {
+		t.Errorf("Expected 6 dropped values, got %d", n)
+	}
+}
+
+func TestSubscribeWithOptionsBlock(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	ch, err := b.SubscribeWithOptions(0, SubscribeOptions[int]{Policy: Block})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// NOTE(njern): Block ignores the broadcaster's timeout.
+	time.Sleep(50 * time.Millisecond)
+
+	select {
+	case v := <-ch:
+		if v != 1 {
+			t.Errorf("Expected 1, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for 1")
+	}
+}
+
+func TestBroadcastSlowSubscribersConcurrently(t *testing.T) {
+	b := New[int](10, 50*time.Millisecond)
+	defer b.Close()
+
+	// NOTE(njern): None of these subscribers ever read.
+	for i := 0; i < 10; i++ {
+		if _, err := b.Subscribe(0); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+
+	start := time.Now()
+	b.broadcast(1)
+
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("Expected timeouts not to accumulate, broadcast took %v", elapsed)
+	}
+}
+
+func benchmarkSlowSubscribers(b *testing.B, sequential bool) {
+	bc := New[int](10, time.Millisecond)
+	defer bc.Close()
+
+	bc.sequential = sequential
+	for i := 0; i < 20; i++ {
+		if _, err := bc.Subscribe(0); err != nil {
+			b.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bc.broadcast(i)
+	}
+}
+
+func BenchmarkBroadcastSlowSubscribersSequential(b *testing.B) {
+	benchmarkSlowSubscribers(b, true)
+}
+
+func BenchmarkBroadcastSlowSubscribersParallel(b *testing.B) {
+	benchmarkSlowSubscribers(b, false)
+}
+
+func TestWithWorkers(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	b := New(10, 0, WithWorkers[int](2))
+	defer b.Close()
+
+	var subs []chan int
+	for i := 0; i < 10; i++ {
+		ch, err := b.Subscribe(100)
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		subs = append(subs, ch)
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	// NOTE(njern): The run loop, the Chan forwarder and the two workers,
+	// no matter how many subscribers there are.
+	if n := runtime.NumGoroutine(); n > baseline+4 {
+		t.Errorf("Expected at most %d goroutines, got %d", baseline+4, n)
+	}
+
+	for i, ch := range subs {
+		if n := len(ch); n != 100 {
+			t.Errorf("Expected subscriber %d to receive 100 values, got %d", i, n)
+		}
+	}
+
+	b.Close()
+	b.WaitClosed()
+
+	waitForGoroutines(t, baseline)
+}
+
+func benchmarkDeliver(b *testing.B, opts ...Option[int]) {
+	bc := New(10, 0, opts...)
+	defer bc.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		subCh, err := bc.Subscribe(10)
+		if err != nil {
+			b.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for n := 0; n < b.N; n++ {
+				<-subCh
+			}
+		}()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bc.Publish(i)
+	}
+
+	wg.Wait()
+}
+
+func BenchmarkDeliverPerSubscriber(b *testing.B) {
+	benchmarkDeliver(b)
+}
+
+func BenchmarkDeliverWorkers(b *testing.B) {
+	benchmarkDeliver(b, WithWorkers[int](4))
+}
+
+func BenchmarkBroadcastWithTimeout(b *testing.B) {
+	bc := New[int](10, time.Second)
+	defer bc.Close()
+
+	for i := 0; i < 4; i++ {
+		subCh, err := bc.Subscribe(10)
+		if err != nil {
+			b.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		go func() {
+			for range subCh {
+			}
+		}()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bc.broadcast(i)
+	}
+}
+
+func TestSubscribeDropOldest(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	subCh, err := b.SubscribeWithOptions(2, SubscribeOptions[int]{Policy: DropOldest})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	for _, want := range []int{2, 3} {
+		select {
+		case val := <-subCh:
+			if val != want {
+				t.Errorf("Expected to receive %d, got %d", want, val)
+			}
+		default:
+			t.Errorf("Expected to receive %d but did not", want)
+		}
+	}
+}
+
+func TestSubscribeBlock(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	subCh, err := b.SubscribeWithOptions(0, SubscribeOptions[int]{Policy: Block})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	for want := 1; want <= 3; want++ {
+		time.Sleep(20 * time.Millisecond) // Slower than the timeout
+
+		select {
+		case val := <-subCh:
+			if val != want {
+				t.Errorf("Expected to receive %d, got %d", want, val)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive %d but did not", want)
+		}
+	}
+}
+
+func TestSubscribeConflated(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	subCh, err := b.SubscribeConflated()
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	select {
+	case val := <-subCh:
+		if val != 3 {
+			t.Errorf("Expected to receive 3, got %d", val)
+		}
+	default:
+		t.Errorf("Expected to receive a message but did not")
+	}
+
+	select {
+	case val := <-subCh:
+		t.Errorf("Expected no stale messages, got %d", val)
+	default:
+	}
+}
+
+func TestReplay(t *testing.T) {
+	b := New(10, 0, WithReplay[int](3))
+	defer b.Close()
+
+	for i := 1; i <= 4; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	// Allow some time for the values to be broadcast
+	time.Sleep(50 * time.Millisecond)
+
+	// NOTE(njern): A buffer smaller than the replay size must not
+	// deadlock Subscribe.
+	subCh, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(5); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for want := 2; want <= 5; want++ {
+		select {
+		case val := <-subCh:
+			if val != want {
+				t.Errorf("Expected to receive %d, got %d", want, val)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive %d but did not", want)
+		}
+	}
+
+	b.Unsubscribe(subCh)
+
+	select {
+	case _, ok := <-subCh:
+		if ok {
+			t.Errorf("Expected subscriber channel to be closed but it was still open")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected subscriber channel to be closed after Unsubscribe")
+	}
+}
+
+func TestSticky(t *testing.T) {
+	b := New(10, 0, WithSticky[int]())
+	defer b.Close()
+
+	for i := 1; i <= 2; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	// Allow some time for the values to be broadcast
+	time.Sleep(50 * time.Millisecond)
+
+	subCh, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	select {
+	case val := <-subCh:
+		if val != 2 {
+			t.Errorf("Expected to receive 2, got %d", val)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected to receive the last value but did not")
+	}
+}
+
+func TestStickyNoPriorValue(t *testing.T) {
+	b := New(10, 0, WithSticky[int]())
+	defer b.Close()
+
+	subCh, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	select {
+	case val := <-subCh:
+		t.Errorf("Expected no value before anything was published, got %d", val)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestOnDrop(t *testing.T) {
+	type dropped struct {
+		ch chan<- int
+		v  int
+	}
+
+	drops := make(chan dropped, 10)
+	b := New(10, 10*time.Millisecond, WithOnDrop(func(ch chan<- int, v int) {
+		drops <- dropped{ch, v}
+	}))
+	defer b.Close()
+
+	// NOTE(njern): This subscriber never reads.
+	subCh, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(42); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case d := <-drops:
+		if d.ch != subCh {
+			t.Errorf("Expected the drop to be reported for the subscriber channel")
+		}
+
+		if d.v != 42 {
+			t.Errorf("Expected dropped value 42, got %d", d.v)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected OnDrop to be called but it was not")
+	}
+}
+
+func TestOnDropCallsBackIntoBroadcaster(t *testing.T) {
+	var b *Broadcaster[int]
+	unsubscribed := make(chan struct{})
+	b = New(10, 10*time.Millisecond, WithOnDrop(func(ch chan<- int, v int) {
+		b.Unsubscribe(ch)
+		close(unsubscribed)
+	}))
+	defer b.Close()
+
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case <-unsubscribed:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected OnDrop to unsubscribe without deadlocking")
+	}
+
+	if got := b.SubscriberCount(); got != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", got)
+	}
+}
+
+func TestSubscriberBacklogs(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	fullCh, err := b.Subscribe(3)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	emptyCh, err := b.Subscribe(3)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	go func() {
+		for range emptyCh {
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	// Allow enough time for timeout and message processing
+	time.Sleep(100 * time.Millisecond)
+
+	backlogs := b.SubscriberBacklogs()
+	if got := backlogs[fullCh]; got != 3 {
+		t.Errorf("Expected a backlog of 3, got %d", got)
+	}
+
+	if got := backlogs[emptyCh]; got != 0 {
+		t.Errorf("Expected a backlog of 0, got %d", got)
+	}
+}
+
+func TestSlowestSubscriber(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	if _, _, ok := b.SlowestSubscriber(); ok {
+		t.Errorf("Expected no slowest subscriber without subscribers")
+	}
+
+	fullCh, reason, err := b.SubscribeWithReason(3)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	emptyCh, err := b.Subscribe(3)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	go func() {
+		for range emptyCh {
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	id, backlog, ok := b.SlowestSubscriber()
+	if !ok {
+		t.Fatalf("Expected a slowest subscriber")
+	}
+
+	if backlog != 3 {
+		t.Errorf("Expected a backlog of 3, got %d", backlog)
+	}
+
+	if want := b.Subscriptions()[0].ID; id != strconv.FormatUint(want, 10) {
+		t.Errorf("Expected ID %d, got %s", want, id)
+	}
+
+	b.DropSubscriber(id)
+	b.DropSubscriber(id)
+	b.DropSubscriber("not an id")
+
+	if got := b.SubscriberCount(); got != 1 {
+		t.Errorf("Expected 1 subscriber, got %d", got)
+	}
+
+	for range fullCh {
+	}
+
+	if err := <-reason; !errors.Is(err, ErrEvicted) {
+		t.Errorf("Expected %v, got %v", ErrEvicted, err)
+	}
+
+	if _, ok := b.SubscriberBacklogs()[emptyCh]; !ok {
+		t.Errorf("Expected the other subscriber to remain")
+	}
+}
+
+func TestSubscribeFilter(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	subCh, err := b.SubscribeFilter(10, func(v int) bool {
+		return v%2 == 0
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	for _, want := range []int{2, 4} {
+		select {
+		case val := <-subCh:
+			if val != want {
+				t.Errorf("Expected to receive %d, got %d", want, val)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive %d but did not", want)
+		}
+	}
+}
+
+func TestSubscribeFilterPanics(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	subCh, err := b.SubscribeFilter(10, func(v int) bool {
+		if v == 1 {
+			panic("boom")
+		}
+
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	select {
+	case val := <-subCh:
+		if val != 2 {
+			t.Errorf("Expected to receive 2, got %d", val)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected the broadcaster to survive a panicking filter")
+	}
+}
+
+func TestSubscribeBlockNeverDrops(t *testing.T) {
+	b := New[int](10, time.Millisecond)
+	defer b.Close()
+
+	subCh, err := b.SubscribeWithOptions(0, SubscribeOptions[int]{Policy: Block})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	const msgCount = 20
+	go func() {
+		for i := 0; i < msgCount; i++ {
+			_ = b.Publish(i)
+		}
+	}()
+
+	for want := 0; want < msgCount; want++ {
+		time.Sleep(2 * time.Millisecond) // Slower than the timeout
+
+		select {
+		case val := <-subCh:
+			if val != want {
+				t.Fatalf("Expected to receive %d, got %d", want, val)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive %d but did not", want)
+		}
+	}
+
+	if dropped := b.Stats().Dropped; dropped != 0 {
+		t.Errorf("Expected no dropped messages, got %d", dropped)
+	}
+}
+
+func TestCloseGracefully(t *testing.T) {
+	b := New[int](10, 0)
+
+	subCh, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	const msgCount = 5
+	for i := 0; i < msgCount; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- b.CloseGracefully(context.Background())
+	}()
+
+	var received []int
+	for val := range subCh {
+		received = append(received, val)
+	}
+
+	if len(received) != msgCount {
+		t.Errorf("Expected to receive %d messages, got %v", msgCount, received)
+	}
+
+	if err := <-closed; err != nil {
+		t.Errorf("Expected CloseGracefully to succeed, got %v", err)
+	}
+
+	if err := b.Publish(1); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+}
+
+func TestCloseGracefullyDeadline(t *testing.T) {
+	b := New[int](10, 0)
+
+	// NOTE(njern): This subscriber never reads, so draining never ends.
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := b.CloseGracefully(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if !b.IsClosed() {
+		t.Errorf("Expected broadcaster to be closed after the deadline")
+	}
+}
+
+func TestCloseDuringBroadcast(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		b := New[int](10, time.Millisecond)
+
+		for j := 0; j < 5; j++ {
+			subCh, err := b.Subscribe(1)
+			if err != nil {
+				t.Fatalf("Failed to subscribe: %v", err)
+			}
+
+			go func() {
+				for range subCh {
+				}
+			}()
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for b.Publish(1) == nil {
+			}
+		}()
+
+		time.Sleep(time.Millisecond)
+		b.Close()
+		wg.Wait()
+	}
+}
+
+func TestPublishAfterCloseFullBuffer(t *testing.T) {
+	b := New[int](1, 0)
+
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): Block the run loop on the first value and fill the
+	// buffer with the second.
+	for i := 0; i < 2; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	b.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if err := b.Publish(3); err != ErrBroadcasterClosed {
+			t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+		}
+
+		if err := b.PublishContext(context.Background(), 4); err != ErrBroadcasterClosed {
+			t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+		}
+
+		if b.TryPublish(5) {
+			t.Errorf("Expected TryPublish to fail on a closed broadcaster")
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected publishing after Close not to block")
+	}
+}
+
+// waitForGoroutines waits for the number of goroutines to drop back to
+// at most n, failing the test if it doesn't.
+func waitForGoroutines(t *testing.T, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Errorf("Expected at most %d goroutines, got %d", n, runtime.NumGoroutine())
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitFor waits for cond to hold, failing the test if it doesn't.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the condition")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubscribeFunc(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	baseline := runtime.NumGoroutine()
+
+	received := make(chan int, 10)
+	cancel, err := b.SubscribeFunc(10, func(v int) {
+		received <- v
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case val := <-received:
+		if val != 1 {
+			t.Errorf("Expected to receive 1, got %d", val)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected fn to be called but it was not")
+	}
+
+	cancel()
+	cancel()
+
+	if err := b.Publish(2); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case val := <-received:
+		t.Errorf("Expected no calls after cancel, got %d", val)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	waitForGoroutines(t, baseline)
+}
+
+func TestSubscribeFuncBroadcasterClosed(t *testing.T) {
+	b := New[int](10, 0)
+	baseline := runtime.NumGoroutine()
+
+	if _, err := b.SubscribeFunc(10, func(int) {}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	b.Close()
+
+	waitForGoroutines(t, baseline-1) // The run loop exits too
+}
+
+func TestHelpersExitOnCancel(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	bj := New[[]byte](10, 0)
+	defer bj.Close()
+
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := b.SubscribeContext(ctx, 10); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	stopFunc, err := b.SubscribeFunc(10, func(int) {})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): Nobody reads the mapped channels, so their
+	// goroutines are blocked sending when they are cancelled.
+	_, stopMap, err := Map(b, 10, func(v int) int { return v })
+	if err != nil {
+		t.Fatalf("Failed to map: %v", err)
+	}
+
+	_, _, stopJSON, err := SubscribeJSON[int](bj, 10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	stopIngest := b.Ingest(make(chan int))
+	d := b.Derive(10, nil)
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := bj.Publish([]byte("1")); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if err := bj.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	cancel()
+	stopFunc()
+	stopMap()
+	stopJSON()
+	stopIngest()
+	d.Close()
+
+	waitForGoroutines(t, baseline)
+}
+
+func TestHelpersExitOnClose(t *testing.T) {
+	b := New[int](10, 0)
+	baseline := runtime.NumGoroutine()
+
+	if _, err := b.SubscribeContext(context.Background(), 10); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if _, err := b.SubscribeFunc(10, func(int) {}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): Nobody reads from the mapped channel, so the Map
+	// goroutine is blocked sending when the broadcaster closes.
+	if _, _, err := Map(b, 10, func(v int) int { return v }); err != nil {
+		t.Fatalf("Failed to map: %v", err)
+	}
+
+	b.Ingest(make(chan int))
+	b.Derive(10, nil)
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	b.Close()
+
+	waitForGoroutines(t, baseline-1) // The run loop exits too
+}
+
+func TestSubscribeContextEvicted(t *testing.T) {
+	b := New(10, time.Millisecond, WithEviction[int](1, nil))
+	defer b.Close()
+
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := b.SubscribeContext(ctx, 1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := range 2 {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	timeout := time.After(time.Second)
+	for closed := false; !closed; {
+		select {
+		case _, ok := <-ch:
+			closed = !ok
+		case <-timeout:
+			t.Fatalf("Timed out waiting for eviction")
+		}
+	}
+
+	// NOTE(njern): ctx is still live, so the goroutine must notice the
+	// eviction on its own.
+	waitForGoroutines(t, baseline)
+}
+
+func TestSubscribePriority(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	// NOTE(njern): The low priority subscriber never reads, so the
+	// broadcast gets stuck on it once it is reached.
+	if _, err := b.SubscribeWithOptions(0, SubscribeOptions[int]{Policy: Block}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	highCh, err := b.SubscribeWithOptions(1, SubscribeOptions[int]{Priority: 1})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case val := <-highCh:
+		if val != 1 {
+			t.Errorf("Expected to receive 1, got %d", val)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected the high priority subscriber to be served first")
+	}
+}
+
+func TestSubscribePriorityOrder(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	subs := make(map[int]chan int)
+	for _, priority := range []int{1, 3, 2} {
+		subCh, err := b.SubscribeWithOptions(0, SubscribeOptions[int]{Priority: priority})
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		subs[priority] = subCh
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// NOTE(njern): The subscribers are unbuffered, so only the one
+	// currently being served is ready to receive.
+	for _, want := range []int{3, 2, 1} {
+		select {
+		case <-subs[1]:
+			if want != 1 {
+				t.Errorf("Expected priority %d to be served, got 1", want)
+			}
+		case <-subs[2]:
+			if want != 2 {
+				t.Errorf("Expected priority %d to be served, got 2", want)
+			}
+		case <-subs[3]:
+			if want != 3 {
+				t.Errorf("Expected priority %d to be served, got 3", want)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected priority %d to be served but it was not", want)
+		}
+	}
+}
+
+func TestPublishBatch(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	subCh, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	vs := []int{1, 2, 3}
+	if err := b.PublishBatch(vs); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// NOTE(njern): Reusing the slice must not affect the batch.
+	vs[0] = 42
+
+	if err := b.Publish(4); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for want := 1; want <= 4; want++ {
+		select {
+		case val := <-subCh:
+			if val != want {
+				t.Errorf("Expected to receive %d, got %d", want, val)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive %d but did not", want)
+		}
+	}
+}
+
+func TestPublishBatchAfterClose(t *testing.T) {
+	b := New[int](10, 0)
+	b.Close()
+
+	if err := b.PublishBatch([]int{1}); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+}
+
+func benchmarkPublish(b *testing.B, batch bool) {
+	const batchSize = 100
+	bc := New[int](10, 0)
+	defer bc.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		subCh, err := bc.Subscribe(batchSize)
+		if err != nil {
+			b.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for n := 0; n < b.N*batchSize; n++ {
+				<-subCh
+			}
+		}()
+	}
+
+	vs := make([]int, batchSize)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if batch {
+			_ = bc.PublishBatch(vs)
+			continue
+		}
+
+		for _, v := range vs {
+			_ = bc.Publish(v)
+		}
+	}
+
+	wg.Wait()
+}
+
+func BenchmarkPublishSingle(b *testing.B) {
+	benchmarkPublish(b, false)
+}
+
+func BenchmarkPublishBatch(b *testing.B) {
+	benchmarkPublish(b, true)
+}
+
+func TestSubscribeDuringSlowBroadcast(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	// NOTE(njern): This subscriber never reads, so the broadcast
+	// below never finishes on its own.
+	stuckCh, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 10; i++ {
+			subCh, err := b.Subscribe(1)
+			if err != nil {
+				t.Errorf("Failed to subscribe: %v", err)
+				return
+			}
+
+			b.Unsubscribe(subCh)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected subscription churn not to wait for the broadcast")
+	}
+
+	// NOTE(njern): Unsubscribing the stuck subscriber lets the
+	// broadcast move on to the next value.
+	b.Unsubscribe(stuckCh)
+
+	subCh, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(2); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case val := <-subCh:
+		if val != 2 {
+			t.Errorf("Expected to receive 2, got %d", val)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected to receive 2 but did not")
+	}
+}
+
+func TestWaitClosed(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	b := New(10, 0, WithReplay[int](3))
+	for i := 0; i < 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	// NOTE(njern): The replay doesn't fit in this buffer, so it is
+	// delivered by a separate goroutine.
+	subCh, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.WaitClosed()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("Expected WaitClosed to block until Close")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	b.Close()
+
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected WaitClosed to return promptly after Close")
+	}
+
+	if _, ok := <-subCh; ok {
+		t.Errorf("Expected subscriber channel to be closed but it was still open")
+	}
+
+	waitForGoroutines(t, baseline)
+}
+
+func TestEviction(t *testing.T) {
+	evicted := make(chan chan<- int, 10)
+	b := New(10, 5*time.Millisecond, WithEviction(3, func(ch chan<- int) {
+		evicted <- ch
+	}))
+	defer b.Close()
+
+	// NOTE(njern): This subscriber never reads.
+	subCh, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := b.SubscriberCount(); got != 1 {
+		t.Fatalf("Expected subscriber to survive two misses, got %d subscribers", got)
+	}
+
+	if err := b.Publish(2); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case ch := <-evicted:
+		if ch != subCh {
+			t.Errorf("Expected the eviction to be reported for the subscriber channel")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected subscriber to be evicted after the third miss")
+	}
+
+	if _, ok := <-subCh; ok {
+		t.Errorf("Expected subscriber channel to be closed but it was still open")
+	}
+
+	if got := b.SubscriberCount(); got != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", got)
+	}
+}
+
+func TestEvictionResetsOnDelivery(t *testing.T) {
+	b := New(10, 50*time.Millisecond, WithEviction[int](2, nil))
+	defer b.Close()
+
+	subCh, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): Miss a value, receive one, then miss another.
+	for _, read := range []bool{false, true, false} {
+		if err := b.Publish(1); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+
+		if !read {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		select {
+		case <-subCh:
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive a message but did not")
+		}
+	}
+
+	if got := b.SubscriberCount(); got != 1 {
+		t.Errorf("Expected subscriber not to be evicted, got %d subscribers", got)
+	}
+}
+
+func TestDone(t *testing.T) {
+	b := New[int](10, 0)
+
+	select {
+	case <-b.Done():
+		t.Fatalf("Expected Done not to fire before Close")
+	default:
+	}
+
+	b.Close()
+
+	select {
+	case <-b.Done():
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected Done to fire after Close")
+	}
+}
+
+func TestSubscribeNegativeBufferSize(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	if _, err := b.Subscribe(-1); err != ErrInvalidBufferSize {
+		t.Errorf("Expected ErrInvalidBufferSize, got %v", err)
+	}
+
+	if got := b.SubscriberCount(); got != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", got)
+	}
+}
+
+func TestSubscribeUnbuffered(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	unbufferedCh, err := b.SubscribeUnbuffered()
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	otherCh, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	// NOTE(njern): Other subscribers receive the current value while
+	// the unbuffered one isn't reading...
+	select {
+	case val := <-otherCh:
+		if val != 1 {
+			t.Errorf("Expected to receive 1, got %d", val)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected to receive 1 but did not")
+	}
+
+	// ...but not the next one.
+	select {
+	case val := <-otherCh:
+		t.Fatalf("Expected the next value to wait for the unbuffered subscriber, got %d", val)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	for want := 1; want <= 2; want++ {
+		select {
+		case val := <-unbufferedCh:
+			if val != want {
+				t.Errorf("Expected to receive %d, got %d", want, val)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive %d but did not", want)
+		}
+	}
+
+	select {
+	case val := <-otherCh:
+		if val != 2 {
+			t.Errorf("Expected to receive 2, got %d", val)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected to receive 2 once the unbuffered subscriber read")
+	}
+}
+
+func TestAll(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	go func() {
+		// NOTE(njern): Wait for the loop below to subscribe.
+		for b.SubscriberCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		for i := 1; i <= 5; i++ {
+			_ = b.Publish(i)
+		}
+	}()
+
+	var received []int
+	for v := range b.All() {
+		received = append(received, v)
+		if v == 3 {
+			break
+		}
+	}
+
+	if len(received) != 3 {
+		t.Errorf("Expected to receive 3 messages, got %v", received)
+	}
+
+	if got := b.SubscriberCount(); got != 0 {
+		t.Errorf("Expected breaking out of the loop to unsubscribe, got %d subscribers", got)
+	}
+}
+
+func TestAllBroadcasterClosed(t *testing.T) {
+	b := New[int](10, 0)
+
+	go func() {
+		for b.SubscriberCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		b.Close()
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for range b.All() {
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the loop to end when the broadcaster closed")
+	}
+}
+
+func TestTTL(t *testing.T) {
+	b := NewWithTTL[int](10, 0, 50*time.Millisecond)
+	defer b.Close()
+
+	subCh, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): The run loop blocks on the first value until we
+	// read it, so the next two wait in the buffer and expire.
+	for i := 1; i <= 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if err := b.Publish(4); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for _, want := range []int{1, 4} {
+		select {
+		case val := <-subCh:
+			if val != want {
+				t.Errorf("Expected to receive %d, got %d", want, val)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive %d but did not", want)
+		}
+	}
+}
+
+func TestFlush(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	subCh, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	go func() {
+		for range subCh {
+			time.Sleep(10 * time.Millisecond) // Simulate slow consumer
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if delivered := b.Stats().Delivered; delivered != 3 {
+		t.Errorf("Expected 3 messages to be delivered before Flush returned, got %d", delivered)
+	}
+}
+
+func TestFlushContext(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	// NOTE(njern): This subscriber never reads.
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := b.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestSubscribeWithReason(t *testing.T) {
+	b := New(10, 5*time.Millisecond, WithEviction[int](1, nil))
+
+	unsubCh, unsubErrCh, err := b.SubscribeWithReason(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): This subscriber never reads, so it is evicted.
+	evictedCh, evictedErrCh, err := b.SubscribeWithReason(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	closedCh, closedErrCh, err := b.SubscribeWithReason(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	b.Unsubscribe(unsubCh)
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case err := <-evictedErrCh:
+		if err != ErrEvicted {
+			t.Errorf("Expected ErrEvicted, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected the evicted subscriber to receive a reason")
+	}
+
+	b.Close()
+
+	for errCh, want := range map[<-chan error]error{
+		unsubErrCh:  nil,
+		closedErrCh: ErrBroadcasterClosed,
+	} {
+		select {
+		case err := <-errCh:
+			if err != want {
+				t.Errorf("Expected %v, got %v", want, err)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive %v but did not", want)
+		}
+	}
+
+	for _, errCh := range []<-chan error{unsubErrCh, evictedErrCh, closedErrCh} {
+		if _, ok := <-errCh; ok {
+			t.Errorf("Expected error channel to be closed after the reason")
+		}
+	}
+
+	for _, subCh := range []chan int{unsubCh, evictedCh} {
+		if _, ok := <-subCh; ok {
+			t.Errorf("Expected subscriber channel to be closed but it was still open")
+		}
+	}
+
+	if val := <-closedCh; val != 1 {
+		t.Errorf("Expected buffered value 1 to survive Close, got %d", val)
+	}
+}
+
+func TestCloseWithError(t *testing.T) {
+	b := New[int](10, 0)
+
+	_, errCh, err := b.SubscribeWithReason(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Err(); err != nil {
+		t.Errorf("Expected no error before Close, got %v", err)
+	}
+
+	closeErr := errors.New("upstream failed")
+	b.CloseWithError(closeErr)
+
+	if err := b.Err(); err != closeErr {
+		t.Errorf("Expected %v, got %v", closeErr, err)
+	}
+
+	if err := <-errCh; err != closeErr {
+		t.Errorf("Expected subscriber to receive %v, got %v", closeErr, err)
+	}
+
+	if _, err := b.Subscribe(10); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+}
+
+func TestCloseErr(t *testing.T) {
+	b := New[int](10, 0)
+	b.Close()
+
+	if err := b.Err(); err != nil {
+		t.Errorf("Expected no error after Close, got %v", err)
+	}
+}
+
+func TestResize(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, err := b.Subscribe(100)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	const n = 1000
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := b.Publish(i); err != nil {
+				t.Errorf("Failed to publish: %v", err)
+				return
+			}
+		}
+	}()
+
+	go func() {
+		for _, size := range []int{100, 1, 50, 0, 10} {
+			if err := b.Resize(size); err != nil {
+				t.Errorf("Failed to resize: %v", err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		select {
+		case v := <-ch:
+			if v != i {
+				t.Fatalf("Expected %d, got %d", i, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", i)
+		}
+	}
+}
+
+func TestResizeShrinkBelowBacklog(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, err := b.SubscribeUnbuffered()
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	resized := make(chan error, 1)
+	go func() {
+		resized <- b.Resize(1)
+	}()
+
+	for i := 0; i < 5; i++ {
+		if v := <-ch; v != i {
+			t.Errorf("Expected %d, got %d", i, v)
+		}
+	}
+
+	if err := <-resized; err != nil {
+		t.Errorf("Failed to resize: %v", err)
+	}
+}
+
+func TestCloseDuringResize(t *testing.T) {
+	b := New[int](10, 0)
+
+	// NOTE(njern): Nobody reads, so the run loop is stuck on the first
+	// value and never picks up the resize.
+	if _, err := b.SubscribeUnbuffered(); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	waitFor(t, func() bool { return b.BufferLen() == 0 })
+
+	resized := make(chan error, 1)
+	go func() {
+		resized <- b.Resize(5)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		b.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for Close")
+	}
+
+	select {
+	case <-resized:
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for Resize")
+	}
+}
+
+func TestPublishDuringResize(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	// NOTE(njern): Nobody reads, so the run loop is stuck on the first
+	// value and never picks up the resize.
+	if _, err := b.SubscribeUnbuffered(); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	waitFor(t, func() bool { return b.BufferLen() == 0 })
+
+	go b.Resize(8)
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if !b.TryPublish(2) {
+			t.Errorf("Expected TryPublish to succeed")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		if err := b.PublishContext(ctx, 3); err != nil {
+			t.Errorf("Failed to publish: %v", err)
+		}
+
+		if n := b.BufferLen(); n != 2 {
+			t.Errorf("Expected 2 buffered messages, got %d", n)
+		}
+
+		if n := b.BufferCap(); n != 10 {
+			t.Errorf("Expected a buffer capacity of 10, got %d", n)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out publishing during a pending resize")
+	}
+}
+
+func TestResizeErrors(t *testing.T) {
+	b := New[int](10, 0)
+
+	if err := b.Resize(-1); err != ErrInvalidBufferSize {
+		t.Errorf("Expected ErrInvalidBufferSize, got %v", err)
+	}
+
+	b.Close()
+
+	if err := b.Resize(10); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+}
+
+func TestIngest(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	src1 := make(chan int)
+	src2 := make(chan int)
+	stop1 := b.Ingest(src1)
+	defer stop1()
+	stop2 := b.Ingest(src2)
+	defer stop2()
+
+	src1 <- 1
+	src2 <- 2
+	close(src1)
+	src2 <- 3
+
+	sum := 0
+	for i := 0; i < 3; i++ {
+		select {
+		case v := <-ch:
+			sum += v
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for value %d", i)
+		}
+	}
+
+	if sum != 6 {
+		t.Errorf("Expected the values to sum to 6, got %d", sum)
+	}
+}
+
+func TestIngestStop(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	src := make(chan int, 1)
+	stop := b.Ingest(src)
+	stop()
+	stop()
+
+	src <- 1
+
+	select {
+	case v := <-ch:
+		t.Errorf("Expected no value after stop, got %d", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestIngestExitsOnClose(t *testing.T) {
+	n := runtime.NumGoroutine()
+
+	b := New[int](10, 0)
+	b.Ingest(make(chan int))
+	b.Close()
+
+	waitForGoroutines(t, n)
+}
+
+func TestOrdered(t *testing.T) {
+	b := New[int](10, 0, WithOrdered[int]())
+	defer b.Close()
+
+	a, err := b.SubscribeUnbuffered()
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	c, err := b.SubscribeUnbuffered()
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+
+		// NOTE(njern): B must not be served while A has yet to read.
+		select {
+		case v := <-c:
+			t.Fatalf("Expected B to wait for A, got %d", v)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		if v := <-a; v != i {
+			t.Errorf("Expected A to receive %d, got %d", i, v)
+		}
+
+		if v := <-c; v != i {
+			t.Errorf("Expected B to receive %d, got %d", i, v)
+		}
+	}
+}
+
+func TestDisconnectPolicy(t *testing.T) {
+	evicted := make(chan chan<- int, 1)
+	b := New[int](10, 0, WithEviction[int](0, func(ch chan<- int) {
+		evicted <- ch
+	}))
+	defer b.Close()
+
+	ch, err := b.SubscribeWithOptions(0, SubscribeOptions[int]{
+		Timeout: 20 * time.Millisecond,
+		Policy:  Disconnect,
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.PublishBatch([]int{1, 2}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case got := <-evicted:
+		if got != ch {
+			t.Errorf("Expected the disconnected channel to be reported")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the subscriber to be disconnected")
+	}
+
+	if _, ok := <-ch; ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", n)
+	}
+}
+
+func TestBufferLenCap(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	// NOTE(njern): Keep the run loop busy delivering the first value so
+	// that the following ones stay in the buffer.
+	ch, err := b.SubscribeUnbuffered()
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for b.BufferLen() != 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := b.BufferLen(); n != 3 {
+		t.Errorf("Expected BufferLen 3, got %d", n)
+	}
+
+	if n := b.BufferCap(); n != 10 {
+		t.Errorf("Expected BufferCap 10, got %d", n)
+	}
+
+	<-ch
+}
+
+func TestInterceptor(t *testing.T) {
+	b := New[int](10, 0, WithInterceptor(func(v int) (int, bool) {
+		switch v {
+		case 1:
+			return 0, false
+		case 2:
+			panic("boom")
+		}
+
+		return v * 10, true
+	}))
+	defer b.Close()
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.PublishBatch([]int{2, 3, 1, 4}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Publish(5); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for _, want := range []int{30, 40, 50} {
+		select {
+		case v := <-ch:
+			if v != want {
+				t.Errorf("Expected %d, got %d", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", want)
+		}
+	}
+
+	select {
+	case v := <-ch:
+		t.Errorf("Expected no more values, got %d", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribeFor(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	start := time.Now()
+	ch, err := b.SubscribeFor(10, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatalf("Expected the channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the channel to close")
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the channel to stay open for 50ms, closed after %v", elapsed)
+	}
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", n)
+	}
+}
+
+func TestSubscribeForExitsOnClose(t *testing.T) {
+	n := runtime.NumGoroutine()
+
+	b := New[int](10, 0)
+	if _, err := b.SubscribeFor(10, time.Hour); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	b.Close()
+
+	waitForGoroutines(t, n)
+}
+
+func TestUnsubscribeTwice(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	b.Unsubscribe(ch)
+	b.Unsubscribe(ch)
+
+	if _, ok := <-ch; ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+}
+
+func TestUnsubscribeUnknownChannel(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch := make(chan int, 1)
+	b.Unsubscribe(ch)
+
+	// NOTE(njern): Sending on a closed channel would panic.
+	ch <- 1
+	if v := <-ch; v != 1 {
+		t.Errorf("Expected 1, got %d", v)
+	}
+}
+
+func TestPublishTo(t *testing.T) {
+	b := New[string](10, 0)
+	defer b.Close()
+
+	admin, err := b.SubscribeWithOptions(10, SubscribeOptions[string]{Tags: []string{"admin"}})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	user, err := b.SubscribeWithOptions(10, SubscribeOptions[string]{Tags: []string{"user"}})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.PublishTo("admin", "secret"); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.PublishTo("nobody", "lost"); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Publish("hello"); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for _, want := range []string{"secret", "hello"} {
+		if v := <-admin; v != want {
+			t.Errorf("Expected admin to receive %q, got %q", want, v)
+		}
+	}
+
+	if v := <-user; v != "hello" {
+		t.Errorf("Expected user to receive %q, got %q", "hello", v)
+	}
+
+	select {
+	case v := <-user:
+		t.Errorf("Expected no more values for user, got %q", v)
+	default:
+	}
+}
+
+func TestSetDeadLetter(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	var mu sync.Mutex
+	var dead []int
+	b.SetDeadLetter(func(v int) {
+		mu.Lock()
+		defer mu.Unlock()
+		dead = append(dead, v)
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(3); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if v := <-ch; v != 3 {
+		t.Errorf("Expected 3, got %d", v)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !slices.Equal(dead, []int{0, 1, 2}) {
+		t.Errorf("Expected dead letters [0 1 2], got %v", dead)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	b.Pause()
+
+	for i := 0; i < 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	select {
+	case v := <-ch:
+		t.Fatalf("Expected no value while paused, got %d", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b.Resume()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case v := <-ch:
+			if v != i {
+				t.Errorf("Expected %d, got %d", i, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", i)
+		}
+	}
+}
+
+func TestPauseClose(t *testing.T) {
+	b := New[int](10, 0)
+
+	b.Pause()
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	b.Close()
+
+	// NOTE(njern): Neither may block once the broadcaster is closed.
+	b.Pause()
+	b.Resume()
+}
+
+func TestNewDedup(t *testing.T) {
+	b := NewDedup[int](10, 0)
+	defer b.Close()
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for _, v := range []int{1, 1, 2, 2, 1} {
+		if err := b.Publish(v); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	var got []int
+	for len(ch) > 0 {
+		got = append(got, <-ch)
+	}
+
+	if !slices.Equal(got, []int{1, 2, 1}) {
+		t.Errorf("Expected [1 2 1], got %v", got)
+	}
+}
+
+func TestSetClone(t *testing.T) {
+	type state struct{ n int }
+
+	b := New[*state](10, 0)
+	defer b.Close()
+
+	b.SetClone(func(s *state) *state {
+		c := *s
+		return &c
+	})
+
+	ch1, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	ch2, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(&state{n: 1}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	s1 := <-ch1
+	s2 := <-ch2
+	s1.n = 2
+
+	if s2.n != 1 {
+		t.Errorf("Expected the second subscriber's value to be unchanged, got %d", s2.n)
+	}
+}
+
+func TestSubscribeOnce(t *testing.T) {
+	b := New[int](10, 0, WithSticky[int]())
+	defer b.Close()
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	go func() {
+		for b.SubscriberCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		if err := b.Publish(2); err != nil {
+			t.Errorf("Failed to publish: %v", err)
+		}
+	}()
+
+	v, err := b.SubscribeOnce(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if v != 2 {
+		t.Errorf("Expected the next value 2, got %d", v)
+	}
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", n)
+	}
+}
+
+func TestAwait(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	go func() {
+		for b.SubscriberCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		for i := 1; i <= 4; i++ {
+			if err := b.Publish(i * 10); err != nil {
+				t.Errorf("Failed to publish: %v", err)
+			}
+		}
+	}()
+
+	v, err := b.Await(context.Background(), func(v int) bool { return v > 20 })
+	if err != nil {
+		t.Fatalf("Failed to await: %v", err)
+	}
+
+	if v != 30 {
+		t.Errorf("Expected the first match 30, got %d", v)
+	}
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", n)
+	}
+}
+
+func TestSubscribeOnceContext(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := b.SubscribeOnce(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", n)
+	}
+}
+
+func TestSubscribeOnceClosed(t *testing.T) {
+	b := New[int](10, 0)
+
+	go func() {
+		for b.SubscriberCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		b.Close()
+	}()
+
+	if _, err := b.SubscribeOnce(context.Background()); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+
+	if _, err := b.SubscribeOnce(context.Background()); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed after Close, got %v", err)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	b := New[int](10, 0)
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	b.CloseWithError(errors.New("first"))
+	b.Close()
+
+	if err := b.CloseGracefully(context.Background()); err != nil {
+		t.Errorf("Expected CloseGracefully after Close to succeed, got %v", err)
+	}
+
+	if _, ok := <-ch; ok {
+		t.Errorf("Expected the channel to stay closed")
+	}
+
+	if err := b.Err(); err == nil || err.Error() != "first" {
+		t.Errorf("Expected the first error to be kept, got %v", err)
+	}
+}
+
+func TestMaxSubscribers(t *testing.T) {
+	b := New[int](10, 0, WithMaxSubscribers[int](2))
+	defer b.Close()
+
+	ch, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if _, err := b.Subscribe(1); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if _, err := b.Subscribe(1); err != ErrTooManySubscribers {
+		t.Errorf("Expected ErrTooManySubscribers, got %v", err)
+	}
+
+	b.Unsubscribe(ch)
+
+	if _, err := b.Subscribe(1); err != nil {
+		t.Errorf("Expected to subscribe once a slot is free, got %v", err)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	b := NewWithHeartbeat[int](10, 0, 20*time.Millisecond, -1)
+	defer b.Close()
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		select {
+		case v := <-ch:
+			if v != -1 {
+				t.Errorf("Expected heartbeat -1, got %d", v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for heartbeat %d", i)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected 3 heartbeats to take about 60ms, took %v", elapsed)
+	}
+}
+
+func TestHeartbeatReset(t *testing.T) {
+	b := NewWithHeartbeat[int](10, 0, 50*time.Millisecond, -1)
+	defer b.Close()
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): Publishing more often than the interval keeps the
+	// heartbeat from firing.
+	for i := 0; i < 5; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+
+		if v := <-ch; v != i {
+			t.Errorf("Expected %d, got %d", i, v)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestHeartbeatNotRecorded(t *testing.T) {
+	b := New(10, 0, WithHeartbeat(10*time.Millisecond, -1), WithSticky[int]())
+	defer b.Close()
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// NOTE(njern): Wait for a heartbeat after the value.
+	for v := range ch {
+		if v == -1 {
+			break
+		}
+	}
+
+	if v, ok := b.Latest(); !ok || v != 1 {
+		t.Errorf("Expected the latest value to be 1, got %d (%v)", v, ok)
+	}
+
+	late, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	select {
+	case v := <-late:
+		if v != 1 {
+			t.Errorf("Expected the sticky value 1, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the sticky value")
+	}
+}
+
+func TestHeartbeatNotPublished(t *testing.T) {
+	b := NewWithHeartbeat[int](10, 0, 5*time.Millisecond, -1)
+	defer b.Close()
+
+	var dead atomic.Int32
+	b.SetDeadLetter(func(int) { dead.Add(1) })
+
+	ch, cancel, err := b.SubscribeSeq(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	select {
+	case m := <-ch:
+		if m.Value != -1 || m.Seq != 0 {
+			t.Errorf("Expected a heartbeat with seq 0, got %d with seq %d", m.Value, m.Seq)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a heartbeat")
+	}
+
+	cancel()
+
+	// NOTE(njern): Without subscribers, heartbeats keep firing but must
+	// not reach the dead letter sink.
+	time.Sleep(30 * time.Millisecond)
+
+	if n := dead.Load(); n != 0 {
+		t.Errorf("Expected 0 dead letters, got %d", n)
+	}
+
+	if n := b.Stats().Published; n != 0 {
+		t.Errorf("Expected 0 published values, got %d", n)
+	}
+}
+
+func TestSetTimeout(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	ch, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// NOTE(njern): Read too late for the value to still be on offer.
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case v := <-ch:
+		t.Fatalf("Expected the value to be dropped, got %d", v)
+	default:
+	}
+
+	b.SetTimeout(time.Second)
+	if d := b.Timeout(); d != time.Second {
+		t.Errorf("Expected timeout 1s, got %v", d)
+	}
+
+	if err := b.Publish(2); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case v := <-ch:
+		if v != 2 {
+			t.Errorf("Expected 2, got %d", v)
+		}
+	default:
+		t.Errorf("Expected the value to wait for the subscriber")
+	}
+}
+
+func TestInputPolicy(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		want   []int
+	}{
+		{DropNewest, []int{1}},
+		{DropOldest, []int{3}},
+		{Block, []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		b := New[int](1, 0, WithInputPolicy[int](tt.policy))
+
+		ch, err := b.Subscribe(10)
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		// NOTE(njern): Stall the run loop so that the buffer fills up.
+		b.Pause()
+
+		published := make(chan struct{})
+		go func() {
+			defer close(published)
+			for i := 1; i <= 3; i++ {
+				if err := b.Publish(i); err != nil {
+					t.Errorf("Failed to publish: %v", err)
+				}
+			}
+		}()
+
+		if tt.policy != Block {
+			select {
+			case <-published:
+			case <-time.After(time.Second):
+				t.Fatalf("Expected policy %d not to block", tt.policy)
+			}
+		}
+
+		b.Resume()
+		<-published
+
+		if err := b.Flush(context.Background()); err != nil {
+			t.Fatalf("Failed to flush: %v", err)
+		}
+
+		var got []int
+		for len(ch) > 0 {
+			got = append(got, <-ch)
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Expected policy %d to deliver %v, got %v", tt.policy, tt.want, got)
+		}
+
+		b.Close()
+	}
+}
+
+func TestInputPolicyDropOldestFlush(t *testing.T) {
+	b := New[int](1, 0, WithInputPolicy[int](DropOldest))
+	defer b.Close()
+
+	ch, err := b.SubscribeUnbuffered()
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): Nobody reads yet, so the run loop is stuck on 1.
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	waitFor(t, func() bool { return b.BufferLen() == 0 })
+
+	flushed := make(chan error, 1)
+	go func() {
+		flushed <- b.Flush(context.Background())
+	}()
+
+	waitFor(t, func() bool { return b.BufferLen() == 1 })
+
+	if err := b.Publish(2); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case err := <-flushed:
+		t.Fatalf("Expected Flush to wait for 1, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if v := <-ch; v != 1 {
+		t.Errorf("Expected 1, got %d", v)
+	}
+
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Errorf("Failed to flush: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for Flush")
+	}
+}
+
+func TestSubscribeGroup(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	var members []chan int
+	for i := 0; i < 2; i++ {
+		ch, err := b.SubscribeWithOptions(10, SubscribeOptions[int]{Group: "workers"})
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		members = append(members, ch)
+	}
+
+	all, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	seen := make(map[int]int)
+	for i, ch := range members {
+		if n := len(ch); n != 5 {
+			t.Errorf("Expected member %d to receive 5 values, got %d", i, n)
+		}
+
+		for len(ch) > 0 {
+			seen[<-ch]++
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		if seen[i] != 1 {
+			t.Errorf("Expected %d to go to exactly one member, got %d", i, seen[i])
+		}
+	}
+
+	// NOTE(njern): Subscribers outside the group still get everything.
+	if n := len(all); n != 10 {
+		t.Errorf("Expected 10 values outside the group, got %d", n)
+	}
+}
+
+func TestUnsubscribeDrain(t *testing.T) {
+	b := New(10, 0, WithReplay[int](5))
+	defer b.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	// NOTE(njern): The replayed backlog doesn't fit in the buffer, so
+	// most of it is still on its way when we unsubscribe.
+	ch, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	drained := b.UnsubscribeDrain(ch)
+
+	for want := 0; want < 5; want++ {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				t.Fatalf("Expected %d, got a closed channel", want)
+			}
+
+			if v != want {
+				t.Errorf("Expected %d, got %d", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", want)
+		}
+	}
+
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the drained channel to be closed")
+	}
+
+	if _, ok := <-ch; ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", n)
+	}
+}
+
+func TestUnsubscribeDrainBuffered(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	<-b.UnsubscribeDrain(ch)
+
+	if err := b.Publish(3); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	var got []int
+	for v := range ch {
+		got = append(got, v)
+	}
+
+	if !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("Expected [0 1 2], got %v", got)
+	}
+
+	select {
+	case <-b.UnsubscribeDrain(ch):
+	default:
+		t.Errorf("Expected UnsubscribeDrain of an unknown channel to return a closed channel")
+	}
+}
+
+func TestSubscribeBackpressure(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	slow, err := b.SubscribeWithOptions(0, SubscribeOptions[int]{Backpressure: true})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// NOTE(njern): Delivery waits for the slow subscriber well past the
+	// timeout, while the other subscriber has long since dropped 1.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := b.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	if n := b.Stats().Dropped; n != 1 {
+		t.Errorf("Expected 1 dropped value, got %d", n)
+	}
+
+	if v := <-slow; v != 1 {
+		t.Errorf("Expected 1, got %d", v)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Errorf("Failed to flush: %v", err)
+	}
+}
+
+func TestUnsubscribeChecked(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	other := New[int](10, 0)
+	defer other.Close()
+
+	foreign, err := other.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.UnsubscribeChecked(foreign); err != ErrNotSubscribed {
+		t.Errorf("Expected %v, got %v", ErrNotSubscribed, err)
+	}
+
+	if err := other.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// NOTE(njern): The foreign channel must still be open and subscribed.
+	if v, ok := <-foreign; !ok || v != 1 {
+		t.Errorf("Expected to receive 1 on an open channel, got %d (open: %t)", v, ok)
+	}
+
+	ch, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.UnsubscribeChecked(ch); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if err := b.UnsubscribeChecked(ch); err != ErrNotSubscribed {
+		t.Errorf("Expected %v for a second call, got %v", ErrNotSubscribed, err)
+	}
+}
+
+func TestSnapshotAndSubscribe(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	_, ok, ch, err := b.SnapshotAndSubscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if ok {
+		t.Errorf("Expected no last value before the first publish")
+	}
+
+	b.Unsubscribe(ch)
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+
+		for i := 0; i < 100; i++ {
+			if err := b.Publish(i); err != nil {
+				t.Errorf("Failed to publish: %v", err)
+			}
+		}
+	}()
+
+	// NOTE(njern): Whatever was broadcast last when we subscribed, every
+	// value after it must arrive.
+	time.Sleep(time.Millisecond)
+	last, ok, ch, err := b.SnapshotAndSubscribe(100)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	want := 0
+	if ok {
+		want = last + 1
+	}
+
+	for ; want < 100; want++ {
+		select {
+		case v := <-ch:
+			if v != want {
+				t.Fatalf("Expected %d, got %d", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", want)
+		}
+	}
+
+	<-published
+}
+
+func TestPublishAndWait(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	read := make(chan struct{})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(read)
+		<-ch
+	}()
+
+	if err := b.PublishAndWait(context.Background(), 1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case <-read:
+	default:
+		t.Errorf("Expected PublishAndWait to return only once the subscriber read")
+	}
+
+	// NOTE(njern): Nobody reads this time.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := b.PublishAndWait(ctx, 2); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRunLoopRecovers(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	b.SetDeadLetter(func(v int) {
+		panic(v)
+	})
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if n := b.Stats().Panics; n != 1 {
+		t.Errorf("Expected 1 recovered panic, got %d", n)
+	}
+
+	// NOTE(njern): The broadcaster must keep working after the panic.
+	ch, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(2); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case v := <-ch:
+		if v != 2 {
+			t.Errorf("Expected 2, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for 2")
+	}
+}
+
+// panickingObserver panics whenever a subscriber receives a value.
+type panickingObserver struct {
+	noopObserver[int]
+}
+
+func (panickingObserver) OnDeliver(v int) {
+	panic(v)
+}
+
+func TestDeliveryRecovers(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option[int]
+	}{
+		{"Goroutines", nil},
+		{"Workers", []Option[int]{WithWorkers[int](2)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]Option[int]{WithObserver[int](panickingObserver{})}, tc.opts...)
+			b := New(10, 0, opts...)
+			defer b.Close()
+
+			ch, err := b.Subscribe(2)
+			if err != nil {
+				t.Fatalf("Failed to subscribe: %v", err)
+			}
+
+			for i := range 2 {
+				if err := b.Publish(i); err != nil {
+					t.Fatalf("Failed to publish: %v", err)
+				}
+			}
+
+			if err := b.Flush(context.Background()); err != nil {
+				t.Fatalf("Failed to flush: %v", err)
+			}
+
+			if n := b.Stats().Panics; n != 2 {
+				t.Errorf("Expected 2 recovered panics, got %d", n)
+			}
+
+			// NOTE(njern): The values were sent before the observer
+			// panicked, so they still arrive.
+			for want := range 2 {
+				if v := <-ch; v != want {
+					t.Errorf("Expected %d, got %d", want, v)
+				}
+			}
+		})
+	}
+
+	t.Run("Seq", func(t *testing.T) {
+		b := New(10, 0, WithObserver[int](panickingObserver{}))
+		defer b.Close()
+
+		ch, cancel, err := b.SubscribeSeq(2)
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+		defer cancel()
+
+		for i := range 2 {
+			if err := b.Publish(i); err != nil {
+				t.Fatalf("Failed to publish: %v", err)
+			}
+		}
+
+		// NOTE(njern): The sequence numbers must be recorded before the
+		// observer panics.
+		for want := range 2 {
+			select {
+			case m := <-ch:
+				if m.Value != want || m.Seq != uint64(want+1) {
+					t.Errorf("Expected %d with seq %d, got %d with seq %d", want, want+1, m.Value, m.Seq)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Timed out waiting for %d", want)
+			}
+		}
+	})
+}
+
+func TestDeliveryRecoversFromClosedChannel(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch := make(chan int, 1)
+	if err := b.SubscribeChan(ch); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	close(ch)
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if n := b.Stats().Panics; n != 1 {
+		t.Errorf("Expected 1 recovered panic, got %d", n)
+	}
+}
+
+func TestSubscribeOnClose(t *testing.T) {
+	b := New[int](10, 0)
+
+	var calls [2]atomic.Int32
+	var chs [2]chan int
+	for i := range chs {
+		ch, err := b.SubscribeWithOptions(1, SubscribeOptions[int]{
+			OnClose: func() { calls[i].Add(1) },
+		})
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		chs[i] = ch
+	}
+
+	b.Unsubscribe(chs[0])
+	b.Unsubscribe(chs[0])
+
+	if n := calls[0].Load(); n != 1 {
+		t.Errorf("Expected OnClose to run once on Unsubscribe, got %d", n)
+	}
+
+	b.Close()
+
+	for i, want := range []int32{1, 1} {
+		if n := calls[i].Load(); n != want {
+			t.Errorf("Expected OnClose of subscriber %d to run %d times, got %d", i, want, n)
+		}
+	}
+}
+
+func TestNilSubscriberChannel(t *testing.T) {
+	b := New[int](10, 0)
+
+	// NOTE(njern): Inject a subscriber without a channel, which the
+	// public API never creates.
+	b.m.Lock()
+	b.add(&subscriber[int]{done: make(chan struct{}), drained: make(chan struct{})})
+	b.m.Unlock()
+
+	ch, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case v := <-ch:
+		if v != 1 {
+			t.Errorf("Expected 1, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for 1")
+	}
+
+	b.Unsubscribe(nil)
+
+	if n := b.SubscriberCount(); n != 1 {
+		t.Errorf("Expected 1 subscriber, got %d", n)
+	}
+
+	b.m.Lock()
+	b.add(&subscriber[int]{done: make(chan struct{}), drained: make(chan struct{})})
+	b.m.Unlock()
+
+	b.Close()
+
+	if _, ok := <-ch; ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	chs, cancel, err := b.FanOut(3, 1)
+	if err != nil {
+		t.Fatalf("Failed to fan out: %v", err)
+	}
+
+	if len(chs) != 3 {
+		t.Fatalf("Expected 3 channels, got %d", len(chs))
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for i, ch := range chs {
+		select {
+		case v := <-ch:
+			if v != 1 {
+				t.Errorf("Expected channel %d to receive 1, got %d", i, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for channel %d", i)
+		}
+	}
+
+	cancel()
+	cancel()
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", n)
+	}
+
+	for i, ch := range chs {
+		if _, ok := <-ch; ok {
+			t.Errorf("Expected channel %d to be closed", i)
+		}
+	}
+}
+
+func TestFanOutInvalidCount(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	if _, _, err := b.FanOut(-1, 1); err != ErrInvalidCount {
+		t.Errorf("Expected ErrInvalidCount, got %v", err)
+	}
+}
+
+func TestFanOutTooManySubscribers(t *testing.T) {
+	b := New(10, 0, WithMaxSubscribers[int](2))
+	defer b.Close()
+
+	if _, _, err := b.FanOut(3, 1); err != ErrTooManySubscribers {
+		t.Errorf("Expected %v, got %v", ErrTooManySubscribers, err)
+	}
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected the partial fan out to be undone, got %d subscribers", n)
+	}
+}
+
+func TestWaitForSubscribers(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	if _, err := b.Subscribe(1); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.WaitForSubscribers(context.Background(), 3)
+	}()
+
+	if _, err := b.Subscribe(1); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		t.Fatalf("Expected to wait for the third subscriber, returned %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if _, err := b.Subscribe(1); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected WaitForSubscribers to return once the third subscriber joined")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := b.WaitForSubscribers(ctx, 4); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestCloseKeepOpen(t *testing.T) {
+	b := New[int](10, 0)
+	baseline := runtime.NumGoroutine()
+
+	ch, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if _, err := b.SubscribeFunc(1, func(int) {}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	b.CloseKeepOpen()
+
+	select {
+	case v, ok := <-ch:
+		t.Errorf("Expected the channel to stay open and empty, got %d (open: %t)", v, ok)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if _, err := b.Subscribe(1); err != ErrBroadcasterClosed {
+		t.Errorf("Expected %v, got %v", ErrBroadcasterClosed, err)
+	}
+
+	if err := b.Publish(1); err != ErrBroadcasterClosed {
+		t.Errorf("Expected %v, got %v", ErrBroadcasterClosed, err)
+	}
+
+	// NOTE(njern): Goroutines reading their own subscription still exit.
+	waitForGoroutines(t, baseline-1) // The run loop exits too
+}
+
+func TestTimeout(t *testing.T) {
+	b := New[int](10, 250*time.Millisecond)
+	defer b.Close()
+
+	if d := b.Timeout(); d != 250*time.Millisecond {
+		t.Errorf("Expected timeout 250ms, got %v", d)
+	}
+
+	if n := b.BufferCap(); n != 10 {
+		t.Errorf("Expected buffer capacity 10, got %d", n)
+	}
+}
+
+func TestPublishAtomic(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			if err := b.Publish(-1); err != nil {
+				return
+			}
+		}
+	}()
+
+	received := make(chan []int)
+	go func() {
+		var got []int
+		for v := range ch {
+			got = append(got, v)
+			if v == 99 {
+				break
+			}
+		}
+
+		received <- got
+	}()
+
+	for i := 0; i < 10; i++ {
+		group := make([]int, 10)
+		for j := range group {
+			group[j] = i*10 + j
+		}
+
+		if err := b.PublishAtomic(group...); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	got := <-received
+	close(stop)
+	b.Close()
+	wg.Wait()
+
+	// NOTE(njern): Each group of ten must arrive without a -1 inside it.
+	var vs []int
+	for i, v := range got {
+		if v < 0 {
+			continue
+		}
+
+		if v%10 != 0 && got[i-1] != v-1 {
+			t.Fatalf("Expected %d right after %d, got %d", v, v-1, got[i-1])
+		}
+
+		vs = append(vs, v)
+	}
+
+	if len(vs) != 100 {
+		t.Errorf("Expected 100 values, got %d", len(vs))
+	}
+}
+
+func TestSubscribeExisting(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch := make(chan int, 3)
+	if err := b.SubscribeExisting(ch); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.SubscribeExisting(ch); err != ErrAlreadySubscribed {
+		t.Errorf("Expected %v, got %v", ErrAlreadySubscribed, err)
+	}
+
+	own, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.SubscribeExisting(own); err != ErrAlreadySubscribed {
+		t.Errorf("Expected %v, got %v", ErrAlreadySubscribed, err)
+	}
+
+	if err := b.SubscribeExisting(nil); err != ErrNilChannel {
+		t.Errorf("Expected %v, got %v", ErrNilChannel, err)
+	}
+
+	if n := b.SubscriberCount(); n != 2 {
+		t.Errorf("Expected 2 subscribers, got %d", n)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case v := <-ch:
+		if v != 1 {
+			t.Errorf("Expected 1, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for 1")
+	}
+
+	b.Unsubscribe(ch)
+
+	if _, ok := <-ch; ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+}
+
+func TestSubscribeChan(t *testing.T) {
+	b := New[int](10, 0)
+
+	ch := make(chan int, 2)
+	if err := b.SubscribeChan(ch); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.SubscribeChan(ch); err != ErrAlreadySubscribed {
+		t.Errorf("Expected %v, got %v", ErrAlreadySubscribed, err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case v := <-ch:
+		if v != 1 {
+			t.Errorf("Expected 1, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for 1")
+	}
+
+	b.Close()
+
+	// NOTE(njern): Sending on a closed channel would panic.
+	ch <- 2
+	if v := <-ch; v != 2 {
+		t.Errorf("Expected the borrowed channel to stay usable, got %d", v)
+	}
+
+	if err := b.SubscribeChan(nil); err != ErrNilChannel {
+		t.Errorf("Expected %v, got %v", ErrNilChannel, err)
+	}
+}
+
+func TestSubscribeChanUnsubscribe(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch := make(chan int, 1)
+	if err := b.SubscribeChan(ch); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	select {
+	case <-b.UnsubscribeDrain(ch):
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the drained channel to be closed")
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	select {
+	case v, ok := <-ch:
+		t.Errorf("Expected nothing on the unsubscribed channel, got %d (open: %t)", v, ok)
+	default:
+	}
+}
+
+func TestLatest(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	if _, ok := b.Latest(); ok {
+		t.Errorf("Expected no latest value before the first publish")
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if v, ok := b.Latest(); !ok || v != 3 {
+		t.Errorf("Expected latest value 3, got %d (ok: %t)", v, ok)
+	}
+}
+
+func TestWithBudget(t *testing.T) {
+	b := New(10, 0, WithBudget[int](20*time.Millisecond), WithOrdered[int]())
+	defer b.Close()
+
+	// NOTE(njern): None of these subscribers ever read, and without the
+	// budget each of them would be waited for indefinitely, one after
+	// the other.
+	for i := 0; i < 5; i++ {
+		if _, err := b.Subscribe(0); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+
+	start := time.Now()
+	if err := b.PublishAndWait(context.Background(), 1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected the broadcast to stay within its budget, took %v", elapsed)
+	}
+
+	if n := b.Stats().Dropped; n != 5 {
+		t.Errorf("Expected 5 dropped values, got %d", n)
+	}
+}
+
+func TestSubscribeDuringBatch(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	// NOTE(njern): Holds up the batch on its first value until we have
+	// subscribed.
+	slow, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.PublishBatch([]int{1, 2, 3}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(4); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for want := 1; want <= 4; want++ {
+		if v := <-slow; v != want {
+			t.Errorf("Expected %d, got %d", want, v)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	// NOTE(njern): None of the batch, then everything after it.
+	if v := <-ch; v != 4 {
+		t.Errorf("Expected the first value after the batch, 4, got %d", v)
+	}
+
+	if n := len(ch); n != 0 {
+		t.Errorf("Expected no more values, got %d", n)
+	}
+}
+
+func TestWithMinTimeout(t *testing.T) {
+	b := New(10, 0, WithMinTimeout[int](10*time.Millisecond))
+	defer b.Close()
+
+	// NOTE(njern): This subscriber never reads, which without the
+	// minimum timeout would stall delivery for good.
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	for want := 0; want < 3; want++ {
+		select {
+		case v := <-ch:
+			if v != want {
+				t.Errorf("Expected %d, got %d", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", want)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if n := b.Stats().Dropped; n != 3 {
+		t.Errorf("Expected 3 dropped values, got %d", n)
+	}
+}
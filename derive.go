@@ -0,0 +1,40 @@
+package broadcast
+
+// Derive returns a new Broadcaster, with the same timeout as b, that
+// broadcasts the values of b for which pred returns true. It subscribes
+// to b with a buffer of size chSize and uses the same size for its own
+// input buffer. The derived broadcaster is closed once b closes, and
+// closing it unsubscribes it from b.
+func (b *Broadcaster[T]) Derive(chSize int, pred func(T) bool) *Broadcaster[T] {
+	d := New[T](chSize, b.Timeout())
+
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{Filter: pred}, subscribeArgs{internal: true})
+	if err != nil {
+		d.Close()
+		return d
+	}
+	ch := sub.out
+
+	go func() {
+		for {
+			select {
+			case v, ok := <-ch:
+				if !ok {
+					d.Close()
+					return
+				}
+
+				// NOTE(njern): Publish only fails once d is closing.
+				if d.Publish(v) != nil {
+					b.Unsubscribe(ch)
+					return
+				}
+			case <-d.closeCh:
+				b.Unsubscribe(ch)
+				return
+			}
+		}
+	}()
+
+	return d
+}
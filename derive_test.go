@@ -0,0 +1,59 @@
+package broadcast
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDerive(t *testing.T) {
+	b := New[int](10, 0)
+
+	even := b.Derive(10, func(v int) bool { return v%2 == 0 })
+
+	ch, err := even.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	for _, want := range []int{0, 2, 4} {
+		select {
+		case v := <-ch:
+			if v != want {
+				t.Errorf("Expected %d, got %d", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", want)
+		}
+	}
+
+	b.Close()
+
+	select {
+	case <-even.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the derived broadcaster to close with its parent")
+	}
+}
+
+func TestDeriveClose(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	n := runtime.NumGoroutine()
+
+	d := b.Derive(10, nil)
+	d.Close()
+
+	waitForGoroutines(t, n)
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", n)
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/njern/broadcast
 
-go 1.22.1
+go 1.23
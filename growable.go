@@ -0,0 +1,100 @@
+package broadcast
+
+// SubscribeGrowable is like Subscribe but the subscriber's buffer starts
+// out with room for initial values and grows on demand, up to max
+// values, to absorb bursts. A max smaller than initial is treated as
+// initial, and at least one value is always buffered. Once the buffer
+// is full, policy decides what happens to further values, like the
+// Policy in SubscribeOptions. For DropOldest the oldest buffered value
+// is discarded, with the others the broadcaster waits on the full
+// buffer as it would for any subscriber.
+//
+// Values stay available on the returned channel until cancel is called
+// or the broadcaster closes, which both close it.
+func (b *Broadcaster[T]) SubscribeGrowable(initial, max int, policy Policy) (<-chan T, func(), error) {
+	if max < initial {
+		max = initial
+	}
+
+	// NOTE(njern): The channel the broadcaster sends on holds size
+	// values and the queue the rest. The queue always has room for at
+	// least one value so there is something to forward.
+	size := initial
+	limit := max - initial
+	if limit < 1 {
+		limit = 1
+		if size > 0 {
+			size--
+		}
+	}
+
+	// NOTE(njern): For DropOldest the queue keeps reading, so that it
+	// holds the oldest values, and discards them itself. The channel
+	// then only passes values through, so the queue holds all max.
+	opts := SubscribeOptions[T]{Policy: policy}
+	if policy == DropOldest {
+		opts.Policy = DropNewest
+		limit = size + limit
+	}
+
+	sub, err := b.subscribe(size, opts, subscribeArgs{internal: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		var queue []T
+		in := sub.out
+		for in != nil || len(queue) > 0 {
+			var (
+				send chan T
+				next T
+			)
+			if len(queue) > 0 {
+				send, next = out, queue[0]
+			}
+
+			recv := in
+			if len(queue) >= limit && policy != DropOldest {
+				recv = nil
+			}
+
+			select {
+			case v, ok := <-recv:
+				if !ok {
+					in = nil
+					continue
+				}
+
+				if len(queue) >= limit {
+					// NOTE(njern): Drops here happen outside delivery,
+					// so only Stats and the Observer see them.
+					old := queue[0]
+					var zero T
+					queue[0] = zero
+					queue = queue[1:]
+
+					b.stats.dropped.Add(1)
+					b.safely(func() { b.observer.OnDrop(old) })
+				}
+
+				queue = append(queue, v)
+			case send <- next:
+				var zero T
+				queue[0] = zero
+				queue = queue[1:]
+				if len(queue) == 0 {
+					// NOTE(njern): Let go of the burst's backing array.
+					queue = nil
+				}
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+
+	return out, func() { b.Unsubscribe(sub.out) }, nil
+}
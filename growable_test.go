@@ -0,0 +1,181 @@
+package broadcast
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSubscribeGrowable(t *testing.T) {
+	b := New[int](10, 50*time.Millisecond)
+	defer b.Close()
+
+	ch, cancel, err := b.SubscribeGrowable(2, 20, DropNewest)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer cancel()
+
+	// NOTE(njern): Nobody reads during the burst, so it has to fit in
+	// the grown buffer.
+	for i := range 20 {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	for want := range 20 {
+		select {
+		case v := <-ch:
+			if v != want {
+				t.Errorf("Expected %d, got %d", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", want)
+		}
+	}
+
+	if n := b.Stats().Dropped; n != 0 {
+		t.Errorf("Expected 0 dropped values, got %d", n)
+	}
+}
+
+func TestSubscribeGrowableFull(t *testing.T) {
+	b := New[int](10, 50*time.Millisecond)
+	defer b.Close()
+
+	ch, cancel, err := b.SubscribeGrowable(2, 5, DropNewest)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer cancel()
+
+	for i := range 8 {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if n := b.Stats().Dropped; n != 3 {
+		t.Errorf("Expected 3 dropped values, got %d", n)
+	}
+
+	for want := range 5 {
+		select {
+		case v := <-ch:
+			if v != want {
+				t.Errorf("Expected %d, got %d", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", want)
+		}
+	}
+}
+
+func TestSubscribeGrowableDropOldest(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, cancel, err := b.SubscribeGrowable(2, 5, DropOldest)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer cancel()
+
+	for i := range 8 {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	// NOTE(njern): The queue may still be catching up with the channel.
+	deadline := time.Now().Add(time.Second)
+	for b.Stats().Dropped != 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := b.Stats().Dropped; n != 3 {
+		t.Errorf("Expected 3 dropped values, got %d", n)
+	}
+
+	for want := 3; want < 8; want++ {
+		select {
+		case v := <-ch:
+			if v != want {
+				t.Errorf("Expected %d, got %d", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", want)
+		}
+	}
+}
+
+func TestSubscribeGrowableSmallMax(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	// NOTE(njern): A max below initial buffers initial values, no more.
+	ch, cancel, err := b.SubscribeGrowable(3, 1, DropNewest)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer cancel()
+
+	for i := range 5 {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if n := b.Stats().Dropped; n != 2 {
+		t.Errorf("Expected 2 dropped values, got %d", n)
+	}
+
+	for want := range 3 {
+		if v := <-ch; v != want {
+			t.Errorf("Expected %d, got %d", want, v)
+		}
+	}
+}
+
+func TestSubscribeGrowableCancel(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	baseline := runtime.NumGoroutine()
+
+	ch, cancel, err := b.SubscribeGrowable(0, 10, DropNewest)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("Expected the channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the channel to close")
+	}
+
+	waitForGoroutines(t, baseline)
+}
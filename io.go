@@ -0,0 +1,58 @@
+package broadcast
+
+import (
+	"fmt"
+	"io"
+)
+
+// ErrInvalidChunkSize is returned by PublishFromReader for a chunk size
+// that is not positive.
+var ErrInvalidChunkSize = fmt.Errorf("chunk size must be positive")
+
+// Writer returns an io.Writer that publishes a copy of every slice
+// written to it, so callers are free to reuse their buffers.
+func Writer(b *Broadcaster[[]byte]) io.Writer {
+	return writer{b}
+}
+
+// writer publishes writes to a Broadcaster.
+type writer struct {
+	b *Broadcaster[[]byte]
+}
+
+// Write copies p and publishes the copy.
+func (w writer) Write(p []byte) (int, error) {
+	if err := w.b.Publish(append([]byte(nil), p...)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// PublishFromReader reads r in chunks of up to bufSize bytes and
+// publishes each chunk until r is exhausted, which returns nil, or
+// until reading or publishing fails, which returns that error. A bufSize
+// that is not positive returns ErrInvalidChunkSize.
+func PublishFromReader(b *Broadcaster[[]byte], r io.Reader, bufSize int) error {
+	if bufSize <= 0 {
+		return ErrInvalidChunkSize
+	}
+
+	buf := make([]byte, bufSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := b.Publish(append([]byte(nil), buf[:n]...)); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
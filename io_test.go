@@ -0,0 +1,117 @@
+package broadcast
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	b := New[[]byte](10, 0)
+	defer b.Close()
+
+	var subs []chan []byte
+	for i := 0; i < 2; i++ {
+		subCh, err := b.Subscribe(10)
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		subs = append(subs, subCh)
+	}
+
+	w := Writer(b)
+	buf := []byte("hello")
+	if _, err := w.Write(buf); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	// NOTE(njern): Reusing the buffer must not affect the value
+	// that was already published.
+	copy(buf, "world")
+
+	if _, err := fmt.Fprint(w, "!"); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	for _, subCh := range subs {
+		for _, want := range []string{"hello", "!"} {
+			select {
+			case val := <-subCh:
+				if string(val) != want {
+					t.Errorf("Expected to receive %q, got %q", want, val)
+				}
+			case <-time.After(100 * time.Millisecond):
+				t.Fatalf("Expected to receive %q but did not", want)
+			}
+		}
+	}
+}
+
+func TestWriterClosed(t *testing.T) {
+	b := New[[]byte](10, 0)
+	b.Close()
+
+	if _, err := Writer(b).Write([]byte("hello")); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+}
+
+func TestPublishFromReader(t *testing.T) {
+	b := New[[]byte](10, 0)
+	defer b.Close()
+
+	var subs []chan []byte
+	for i := 0; i < 2; i++ {
+		subCh, err := b.Subscribe(10)
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		subs = append(subs, subCh)
+	}
+
+	const content = "the quick brown fox jumps over the lazy dog"
+	if err := PublishFromReader(b, bytes.NewReader([]byte(content)), 8); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for _, subCh := range subs {
+		var got []byte
+		for len(got) < len(content) {
+			select {
+			case val := <-subCh:
+				got = append(got, val...)
+			case <-time.After(100 * time.Millisecond):
+				t.Fatalf("Expected to receive %q, got %q", content, got)
+			}
+		}
+
+		if string(got) != content {
+			t.Errorf("Expected to receive %q, got %q", content, got)
+		}
+	}
+}
+
+func TestPublishFromReaderClosed(t *testing.T) {
+	b := New[[]byte](10, 0)
+	b.Close()
+
+	err := PublishFromReader(b, bytes.NewReader([]byte("hello")), 8)
+	if err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+}
+
+func TestPublishFromReaderInvalidSize(t *testing.T) {
+	b := New[[]byte](10, 0)
+	defer b.Close()
+
+	for _, size := range []int{0, -1} {
+		err := PublishFromReader(b, bytes.NewReader([]byte("hello")), size)
+		if err != ErrInvalidChunkSize {
+			t.Errorf("Expected ErrInvalidChunkSize for size %d, got %v", size, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package broadcast
+
+import "encoding/json"
+
+// SubscribeJSON subscribes to b and returns a channel of the values
+// decoded from each broadcast JSON document. Values that fail to decode
+// are skipped and their errors sent on the returned error channel
+// instead, so both channels must be read from until they are closed.
+// That happens once the broadcaster closes, or once the returned cancel
+// func has ended the subscription.
+func SubscribeJSON[T any](b *Broadcaster[[]byte], chSize int) (<-chan T, <-chan error, func(), error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[[]byte]{}, subscribeArgs{internal: true})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	out := make(chan T)
+	errCh := make(chan error)
+	go func() {
+		defer close(out)
+		defer close(errCh)
+
+		for data := range sub.out {
+			var v T
+			if err := json.Unmarshal(data, &v); err != nil {
+				select {
+				case errCh <- err:
+				case <-sub.done:
+					return
+				}
+
+				continue
+			}
+
+			select {
+			case out <- v:
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+
+	return out, errCh, func() { b.Unsubscribe(sub.out) }, nil
+}
@@ -0,0 +1,75 @@
+package broadcast
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribeJSON(t *testing.T) {
+	type event struct {
+		Name string `json:"name"`
+	}
+
+	b := New[[]byte](10, 0)
+
+	ch, errCh, cancel, err := SubscribeJSON[event](b, 10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	defer cancel()
+
+	for _, data := range []string{`{"name":"a"}`, `{"name":`, `{"name":"b"}`} {
+		if err := b.Publish([]byte(data)); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	select {
+	case v := <-ch:
+		if v.Name != "a" {
+			t.Errorf("Expected %q, got %q", "a", v.Name)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the first value")
+	}
+
+	// NOTE(njern): The malformed value must not end the subscription.
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Errorf("Expected a decode error, got nil")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the decode error")
+	}
+
+	select {
+	case v := <-ch:
+		if v.Name != "b" {
+			t.Errorf("Expected %q, got %q", "b", v.Name)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the second value")
+	}
+
+	b.Close()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("Expected the value channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the value channel to be closed after Close")
+	}
+
+	select {
+	case _, ok := <-errCh:
+		if ok {
+			t.Errorf("Expected the error channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the error channel to be closed after Close")
+	}
+}
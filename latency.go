@@ -0,0 +1,78 @@
+package broadcast
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// LatencyStats summarizes how long broadcasts took to reach every
+// subscriber. Each percentile is rounded up to the next power of two
+// nanoseconds.
+type LatencyStats struct {
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+}
+
+// histogram counts durations in buckets growing by powers of two, so
+// that recording one is a single atomic add.
+type histogram struct {
+	buckets [64]atomic.Uint64 // Bucket i counts durations below 2^i ns
+}
+
+// record adds d to the histogram.
+func (h *histogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	i := bits.Len64(uint64(d))
+	if i >= len(h.buckets) {
+		i = len(h.buckets) - 1
+	}
+
+	h.buckets[i].Add(1)
+}
+
+// percentiles returns the upper bound of the bucket holding each of
+// the quantiles qs, or zero if nothing was recorded.
+func (h *histogram) percentiles(qs ...float64) []time.Duration {
+	var counts [64]uint64
+	var total uint64
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+		total += counts[i]
+	}
+
+	ds := make([]time.Duration, len(qs))
+	if total == 0 {
+		return ds
+	}
+
+	for j, q := range qs {
+		rank := uint64(q * float64(total))
+		if rank == 0 {
+			rank = 1
+		}
+
+		var seen uint64
+		for i, n := range counts {
+			seen += n
+			if seen >= rank {
+				ds[j] = time.Duration(uint64(1) << i)
+				break
+			}
+		}
+	}
+
+	return ds
+}
+
+// LatencyStats returns the percentiles of how long each broadcast took
+// to deliver its values to every subscriber, including the time spent
+// waiting for slow ones.
+func (b *Broadcaster[T]) LatencyStats() LatencyStats {
+	ps := b.latency.percentiles(0.50, 0.95, 0.99)
+	return LatencyStats{P50: ps[0], P95: ps[1], P99: ps[2]}
+}
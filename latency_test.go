@@ -0,0 +1,62 @@
+package broadcast
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLatencyStats(t *testing.T) {
+	b := New[int](10, 20*time.Millisecond)
+	defer b.Close()
+
+	if got := b.LatencyStats(); got != (LatencyStats{}) {
+		t.Errorf("Expected no latency before broadcasting, got %+v", got)
+	}
+
+	// NOTE(njern): This subscriber never reads, so every broadcast
+	// waits for the full timeout.
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	got := b.LatencyStats()
+	if got.P50 < 20*time.Millisecond {
+		t.Errorf("Expected P50 of at least 20ms, got %v", got.P50)
+	}
+
+	if got.P99 < got.P95 || got.P95 < got.P50 {
+		t.Errorf("Expected percentiles to be ordered, got %+v", got)
+	}
+}
+
+func TestHistogramPercentiles(t *testing.T) {
+	var h histogram
+	for i := 0; i < 99; i++ {
+		h.record(time.Microsecond)
+	}
+	h.record(time.Second)
+
+	ps := h.percentiles(0.5, 0.99, 1)
+	if ps[0] != 1024*time.Nanosecond {
+		t.Errorf("Expected P50 of 1.024µs, got %v", ps[0])
+	}
+
+	if ps[1] != 1024*time.Nanosecond {
+		t.Errorf("Expected P99 of 1.024µs, got %v", ps[1])
+	}
+
+	if ps[2] < time.Second {
+		t.Errorf("Expected the maximum to be at least 1s, got %v", ps[2])
+	}
+}
@@ -0,0 +1,14 @@
+package broadcast
+
+// A Logger receives debug messages about a Broadcaster's lifecycle. It
+// is satisfied by *log.Logger, among others.
+type Logger interface {
+	Printf(format string, args ...any)
+}
+
+// logf logs a debug message if a logger was set with WithLogger.
+func (b *Broadcaster[T]) logf(format string, args ...any) {
+	if b.logger != nil {
+		b.logger.Printf(format, args...)
+	}
+}
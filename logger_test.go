@@ -0,0 +1,79 @@
+package broadcast
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// capturingLogger keeps every line logged to it.
+type capturingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *capturingLogger) Printf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestWithLogger(t *testing.T) {
+	l := &capturingLogger{}
+	b := New[int](10, 10*time.Millisecond, WithLogger[int](l))
+
+	ch, err := b.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// Allow enough time for timeout and message processing
+	time.Sleep(50 * time.Millisecond)
+
+	b.Unsubscribe(ch)
+	b.Close()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, event := range []string{"subscribed", "dropped 1", "unsubscribed", "closed"} {
+		found := false
+		for _, line := range l.lines {
+			if strings.Contains(line, event) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Errorf("Expected %q to be logged, got %q", event, l.lines)
+		}
+	}
+}
+
+func TestWithLoggerUnbufferedWarning(t *testing.T) {
+	l := &capturingLogger{}
+	b := New[int](10, 0, WithLogger[int](l))
+	defer b.Close()
+
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, line := range l.lines {
+		if strings.Contains(line, "no timeout") {
+			return
+		}
+	}
+
+	t.Errorf("Expected a warning about an unbuffered subscriber without a timeout, got %q", l.lines)
+}
@@ -0,0 +1,27 @@
+package broadcast
+
+// Map subscribes to b and returns a channel of the values transformed
+// by fn, along with a cancel func that ends the subscription once the
+// mapped values are no longer needed, even if nobody reads them. The
+// mapped channel is closed by cancel, or once the broadcaster closes.
+func Map[In, Out any](b *Broadcaster[In], chSize int, fn func(In) Out) (<-chan Out, func(), error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[In]{}, subscribeArgs{internal: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan Out)
+	go func() {
+		defer close(out)
+
+		for v := range sub.out {
+			select {
+			case out <- fn(v):
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+
+	return out, func() { b.Unsubscribe(sub.out) }, nil
+}
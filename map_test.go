@@ -0,0 +1,81 @@
+package broadcast
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	b := New[int](10, 0)
+
+	strCh, cancel, err := Map(b, 10, strconv.Itoa)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	defer cancel()
+
+	for i := 1; i <= 2; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	for _, want := range []string{"1", "2"} {
+		select {
+		case val := <-strCh:
+			if val != want {
+				t.Errorf("Expected to receive %q, got %q", want, val)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive %q but did not", want)
+		}
+	}
+
+	b.Close()
+
+	select {
+	case _, ok := <-strCh:
+		if ok {
+			t.Errorf("Expected mapped channel to be closed but it was still open")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Expected mapped channel to be closed after Close")
+	}
+}
+
+func TestMapCancel(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	baseline := runtime.NumGoroutine()
+
+	strCh, cancel, err := Map(b, 10, strconv.Itoa)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): Nobody reads, so the Map goroutine is blocked
+	// sending when it is cancelled.
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	cancel()
+
+	if got := b.SubscriberCount(); got != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", got)
+	}
+
+	waitForGoroutines(t, baseline)
+
+	for range strCh {
+	}
+}
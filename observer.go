@@ -0,0 +1,22 @@
+package broadcast
+
+// An Observer is notified of a Broadcaster's activity, for example to
+// feed a metrics system. Its methods may be called concurrently, while
+// the broadcaster holds internal locks, so they must return quickly and
+// must not call back into the broadcaster.
+type Observer[T any] interface {
+	OnPublish(v T)  // A value is about to be broadcast
+	OnDeliver(v T)  // A subscriber received a value
+	OnDrop(v T)     // A subscriber missed a value
+	OnSubscribe()   // A subscriber was added
+	OnUnsubscribe() // A subscriber was removed or closed by Close
+}
+
+// noopObserver is the Observer used when none is set.
+type noopObserver[T any] struct{}
+
+func (noopObserver[T]) OnPublish(T)    {}
+func (noopObserver[T]) OnDeliver(T)    {}
+func (noopObserver[T]) OnDrop(T)       {}
+func (noopObserver[T]) OnSubscribe()   {}
+func (noopObserver[T]) OnUnsubscribe() {}
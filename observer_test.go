@@ -0,0 +1,100 @@
+package broadcast
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingObserver counts the calls to each Observer method.
+type recordingObserver struct {
+	mu                            sync.Mutex
+	published, delivered, dropped int
+	subscribed, unsubscribed      int
+}
+
+func (o *recordingObserver) OnPublish(int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.published++
+}
+
+func (o *recordingObserver) OnDeliver(int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.delivered++
+}
+
+func (o *recordingObserver) OnDrop(int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.dropped++
+}
+
+func (o *recordingObserver) OnSubscribe() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.subscribed++
+}
+
+func (o *recordingObserver) OnUnsubscribe() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.unsubscribed++
+}
+
+func TestObserver(t *testing.T) {
+	o := &recordingObserver{}
+	b := New[int](10, 10*time.Millisecond, WithObserver[int](o))
+
+	ch, err := b.Subscribe(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): This subscriber never reads.
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		<-ch
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	b.Unsubscribe(ch)
+	b.Close()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.published != 3 {
+		t.Errorf("Expected 3 publishes, got %d", o.published)
+	}
+
+	if o.delivered != 3 {
+		t.Errorf("Expected 3 deliveries, got %d", o.delivered)
+	}
+
+	if o.dropped != 3 {
+		t.Errorf("Expected 3 drops, got %d", o.dropped)
+	}
+
+	if o.subscribed != 2 {
+		t.Errorf("Expected 2 subscribes, got %d", o.subscribed)
+	}
+
+	if o.unsubscribed != 2 {
+		t.Errorf("Expected 2 unsubscribes, got %d", o.unsubscribed)
+	}
+}
@@ -0,0 +1,178 @@
+package broadcast
+
+import "time"
+
+// An Option configures a Broadcaster.
+type Option[T any] func(*Broadcaster[T])
+
+// WithReplay keeps the last n broadcast values and delivers them to
+// every new subscriber, in order, before any live values.
+func WithReplay[T any](n int) Option[T] {
+	return func(b *Broadcaster[T]) {
+		if n > 0 {
+			b.replay = newRing[Message[T]](n)
+		}
+	}
+}
+
+// WithSticky delivers the most recently broadcast value, if any, to
+// every new subscriber before any live values.
+func WithSticky[T any]() Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.sticky = true
+	}
+}
+
+// WithOrdered delivers each value to one subscriber at a time, in the
+// order they subscribed, instead of to all of them concurrently.
+// Subscribers with a higher priority are still served first. A slow
+// subscriber then delays every subscriber after it.
+func WithOrdered[T any]() Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.sequential = true
+	}
+}
+
+// WithWorkers delivers values using a pool of n goroutines, started by
+// New, instead of a new goroutine per subscriber for every value. This
+// bounds the number of goroutines, but a value then reaches at most n
+// subscribers at once, so timeouts accumulate when more than n of them
+// are slow. It has no effect together with WithOrdered.
+func WithWorkers[T any](n int) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.workers = n
+	}
+}
+
+// WithMinTimeout makes the broadcaster wait at most d for subscribers
+// with an unbuffered channel that would otherwise wait indefinitely,
+// because neither they nor the broadcaster set a timeout. Without it, a
+// single such subscriber that stops reading stalls delivery to every
+// other subscriber, and eventually the publisher. Subscribers with the
+// Block policy still wait as long as it takes.
+func WithMinTimeout[T any](d time.Duration) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.minTimeout = d
+	}
+}
+
+// WithBudget limits the time spent delivering each value to d in total,
+// across all subscribers, instead of only limiting the time spent on
+// each of them. Subscribers that haven't received the value by then
+// miss it, even those with a zero timeout. Subscribers with the Block
+// policy are exempt, and still hold up delivery for as long as they
+// take.
+func WithBudget[T any](d time.Duration) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.budget = d
+	}
+}
+
+// WithObserver notifies o of the broadcaster's activity.
+func WithObserver[T any](o Observer[T]) Option[T] {
+	return func(b *Broadcaster[T]) {
+		if o != nil {
+			b.observer = o
+		}
+	}
+}
+
+// WithOnDrop calls fn whenever a subscriber misses a value, either
+// because it timed out or because the value was discarded to make room
+// for a newer one. fn is called on the broadcast goroutine after each
+// broadcast completes, so it should return quickly.
+func WithOnDrop[T any](fn func(ch chan<- T, v T)) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.onDrop = fn
+	}
+}
+
+// WithEviction unsubscribes, and so closes the channel of, any
+// subscriber that times out on n values in a row. If onEvict is not
+// nil it is called with the evicted channel, on the broadcast
+// goroutine, after each broadcast completes. It is also called for
+// subscribers evicted by the Disconnect policy, even if n is zero.
+func WithEviction[T any](n int, onEvict func(ch chan<- T)) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.evictAfter = n
+		b.onEvict = onEvict
+	}
+}
+
+// WithTTL discards values that have waited in the input buffer for
+// longer than ttl by the time they are about to be broadcast.
+func WithTTL[T any](ttl time.Duration) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.ttl = ttl
+	}
+}
+
+// WithInterceptor calls fn on every published value just before it is
+// broadcast. The value fn returns is broadcast in its place, unless fn
+// returns false, in which case the value is skipped. A value is also
+// skipped if fn panics. fn is called on the broadcast goroutine, so it
+// should return quickly.
+func WithInterceptor[T any](fn func(v T) (T, bool)) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.interceptor = fn
+	}
+}
+
+// WithRateLimit broadcasts at most maxPerSec values per second, evenly
+// spaced. Excess values wait in the input buffer, unless drop is set,
+// in which case they are discarded. A batch counts as one value per
+// element but is broadcast, or discarded, as a whole.
+func WithRateLimit[T any](maxPerSec int, drop bool) Option[T] {
+	return func(b *Broadcaster[T]) {
+		if maxPerSec > 0 {
+			b.limiter = &limiter{interval: time.Second / time.Duration(maxPerSec), drop: drop}
+		}
+	}
+}
+
+// WithLogger logs new and ended subscriptions, dropped values and
+// closing the broadcaster to l. By default nothing is logged. l is only
+// ever called without holding any of the broadcaster's locks, but drops
+// are logged on the broadcast goroutine, so it should return quickly.
+func WithLogger[T any](l Logger) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.logger = l
+	}
+}
+
+// WithMaxSubscribers limits the broadcaster to n subscribers at a time.
+// Subscribing beyond that returns ErrTooManySubscribers. A zero n
+// allows any number of subscribers.
+func WithMaxSubscribers[T any](n int) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.maxSubscribers = n
+	}
+}
+
+// WithHeartbeat broadcasts v whenever nothing else has been broadcast
+// for interval, so that idle subscribers can tell that the broadcaster
+// is still alive. No heartbeats are sent while paused. Heartbeats only
+// reach the current subscribers: they get no sequence number, are not
+// replayed, don't replace the value returned by Latest, don't count as
+// published, in Stats or for Observer.OnPublish, and never go to the
+// func set by SetDeadLetter.
+func WithHeartbeat[T any](interval time.Duration, v T) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.heartbeat = interval
+		b.heartbeatValue = v
+	}
+}
+
+// WithInputPolicy decides what publishing does when the input buffer is
+// full. Block, the default, waits for room. DropNewest discards the
+// value being published and DropOldest the oldest buffered one, so that
+// publishers never wait. Either way Publish returns nil. DropOldest never
+// discards a pending Flush, so if nothing else is buffered, the value
+// being published is discarded instead. Disconnect
+// behaves like Block, and so does every policy for an unbuffered
+// broadcaster.
+func WithInputPolicy[T any](p Policy) Option[T] {
+	return func(b *Broadcaster[T]) {
+		b.inputPolicy = p
+	}
+}
@@ -0,0 +1,55 @@
+package broadcast
+
+import "time"
+
+// A limiter spaces out broadcasts so that they don't exceed a rate.
+type limiter struct {
+	interval time.Duration // Time reserved for each value
+	drop     bool          // Discard values instead of waiting
+	next     time.Time     // When the next value may be broadcast
+}
+
+// reserve makes room for n values. It returns how long to wait before
+// broadcasting them, or false if they must be discarded.
+func (l *limiter) reserve(n int) (time.Duration, bool) {
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	wait := l.next.Sub(now)
+	if wait > 0 && l.drop {
+		return 0, false
+	}
+
+	l.next = l.next.Add(time.Duration(n) * l.interval)
+	return wait, true
+}
+
+// throttle waits until n values may be broadcast under the rate limit
+// set by WithRateLimit. It returns false if they must be discarded
+// instead, or if the broadcaster was closed while waiting.
+func (b *Broadcaster[T]) throttle(n int) bool {
+	if b.limiter == nil {
+		return true
+	}
+
+	wait, ok := b.limiter.reserve(n)
+	if !ok {
+		return false
+	}
+
+	if wait == 0 {
+		return true
+	}
+
+	t := getTimer(wait)
+	defer putTimer(t)
+
+	select {
+	case <-t.C:
+		return true
+	case <-b.closeCh:
+		return false
+	}
+}
@@ -0,0 +1,68 @@
+package broadcast
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	b := NewWithRateLimit[int](100, 0, 100)
+	defer b.Close()
+
+	ch, err := b.Subscribe(100)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		select {
+		case v := <-ch:
+			if v != i {
+				t.Errorf("Expected %d, got %d", i, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", i)
+		}
+	}
+
+	// NOTE(njern): The first value goes out right away and each of the
+	// others 10ms after the previous one.
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("Expected delivery to take at least 190ms, took %v", elapsed)
+	}
+}
+
+func TestRateLimitDrop(t *testing.T) {
+	b := New[int](100, 0, WithRateLimit[int](10, true))
+	defer b.Close()
+
+	ch, err := b.Subscribe(100)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if v := <-ch; v != 0 {
+		t.Errorf("Expected 0, got %d", v)
+	}
+
+	// NOTE(njern): Everything else was published within the first
+	// 100ms, so it was discarded.
+	select {
+	case v := <-ch:
+		t.Errorf("Expected the other values to be dropped, got %d", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
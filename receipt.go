@@ -0,0 +1,56 @@
+package broadcast
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// A DeliveryReceipt reports the outcome of broadcasting a single value.
+type DeliveryReceipt struct {
+	Delivered int // Subscribers that received the value
+	Dropped   int // Subscribers that timed out and missed it
+}
+
+// PublishReceipt is like Publish but returns a channel that receives a
+// DeliveryReceipt once the value has been broadcast. A value that is
+// never broadcast, for example because it was dropped from the input
+// buffer, gets an empty receipt. If the broadcaster is closed before
+// the value is handled no receipt is sent, so callers waiting on the
+// channel should also wait on Done.
+func (b *Broadcaster[T]) PublishReceipt(v T) (<-chan DeliveryReceipt, error) {
+	receipt := make(chan DeliveryReceipt, 1)
+	if err := b.enqueue(context.Background(), message[T]{v: v, receipt: receipt}); err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// tally counts the outcome of the deliveries for a DeliveryReceipt. A
+// nil tally counts nothing.
+type tally struct {
+	delivered atomic.Int64
+	dropped   atomic.Int64
+}
+
+// deliver counts a subscriber that received the value.
+func (t *tally) deliver() {
+	if t != nil {
+		t.delivered.Add(1)
+	}
+}
+
+// drop counts a subscriber that missed the value.
+func (t *tally) drop() {
+	if t != nil {
+		t.dropped.Add(1)
+	}
+}
+
+// receipt returns the counts so far.
+func (t *tally) receipt() DeliveryReceipt {
+	return DeliveryReceipt{
+		Delivered: int(t.delivered.Load()),
+		Dropped:   int(t.dropped.Load()),
+	}
+}
@@ -0,0 +1,44 @@
+package broadcast
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPublishReceipt(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	if _, err := b.Subscribe(1); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): This subscriber never reads.
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	receipt, err := b.PublishReceipt(1)
+	if err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case r := <-receipt:
+		want := DeliveryReceipt{Delivered: 1, Dropped: 1}
+		if r != want {
+			t.Errorf("Expected %+v, got %+v", want, r)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the receipt")
+	}
+}
+
+func TestPublishReceiptClosed(t *testing.T) {
+	b := New[int](10, 0)
+	b.Close()
+
+	if _, err := b.PublishReceipt(1); err != ErrBroadcasterClosed {
+		t.Errorf("Expected %v, got %v", ErrBroadcasterClosed, err)
+	}
+}
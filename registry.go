@@ -0,0 +1,95 @@
+package broadcast
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrTopicNotFound is returned by GetTopic for a topic that was never
+// registered.
+var ErrTopicNotFound = fmt.Errorf("topic not found")
+
+// ErrTopicType is returned by GetTopic when the topic carries values of
+// a different type than the one requested.
+var ErrTopicType = fmt.Errorf("topic has a different type")
+
+// A Registry holds named broadcasters that may each carry a different
+// type of value, so that they can be looked up and closed together.
+type Registry struct {
+	m       sync.Mutex // Protects the topics map
+	topics  map[string]interface{ Close() }
+	closed  bool
+	n       int
+	timeout time.Duration
+}
+
+// NewRegistry creates a new Registry whose topics are created as if by
+// New(n, timeout).
+func NewRegistry(n int, timeout time.Duration) *Registry {
+	return &Registry{
+		topics:  make(map[string]interface{ Close() }),
+		n:       n,
+		timeout: timeout,
+	}
+}
+
+// RegisterTopic creates a broadcaster for values of type T named name
+// and adds it to r. If the topic is already registered with the same
+// type, its broadcaster is returned instead. It panics if the topic is
+// registered with a different type. Once r has been closed, the
+// returned broadcaster is already closed.
+func RegisterTopic[T any](r *Registry, name string, opts ...Option[T]) *Broadcaster[T] {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if t, ok := r.topics[name]; ok {
+		b, ok := t.(*Broadcaster[T])
+		if !ok {
+			panic(fmt.Sprintf("broadcast: topic %q is already registered with a different type", name))
+		}
+
+		return b
+	}
+
+	b := New(r.n, r.timeout, opts...)
+	if r.closed {
+		b.Close()
+		return b
+	}
+
+	r.topics[name] = b
+	return b
+}
+
+// GetTopic returns the broadcaster registered as name. It returns
+// ErrTopicNotFound if there is none and ErrTopicType if it carries
+// values of another type than T.
+func GetTopic[T any](r *Registry, name string) (*Broadcaster[T], error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	t, ok := r.topics[name]
+	if !ok {
+		return nil, ErrTopicNotFound
+	}
+
+	b, ok := t.(*Broadcaster[T])
+	if !ok {
+		return nil, ErrTopicType
+	}
+
+	return b, nil
+}
+
+// CloseAll closes every registered broadcaster and removes it from r.
+func (r *Registry) CloseAll() {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.closed = true
+	for name, t := range r.topics {
+		t.Close()
+		delete(r.topics, name)
+	}
+}
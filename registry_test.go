@@ -0,0 +1,60 @@
+package broadcast
+
+import "testing"
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(10, 0)
+
+	ints := RegisterTopic[int](r, "ints")
+	strs := RegisterTopic[string](r, "strings")
+
+	if got := RegisterTopic[int](r, "ints"); got != ints {
+		t.Errorf("Expected registering again to return the same broadcaster")
+	}
+
+	got, err := GetTopic[int](r, "ints")
+	if err != nil {
+		t.Fatalf("Failed to get topic: %v", err)
+	}
+
+	if got != ints {
+		t.Errorf("Expected GetTopic to return the registered broadcaster")
+	}
+
+	if _, err := GetTopic[int](r, "strings"); err != ErrTopicType {
+		t.Errorf("Expected ErrTopicType, got %v", err)
+	}
+
+	if _, err := GetTopic[int](r, "missing"); err != ErrTopicNotFound {
+		t.Errorf("Expected ErrTopicNotFound, got %v", err)
+	}
+
+	r.CloseAll()
+
+	if !ints.IsClosed() || !strs.IsClosed() {
+		t.Errorf("Expected CloseAll to close every topic")
+	}
+
+	if _, err := GetTopic[int](r, "ints"); err != ErrTopicNotFound {
+		t.Errorf("Expected ErrTopicNotFound after CloseAll, got %v", err)
+	}
+
+	if b := RegisterTopic[int](r, "late"); !b.IsClosed() {
+		t.Errorf("Expected a topic registered after CloseAll to be closed")
+	}
+}
+
+func TestRegisterTopicTypeMismatch(t *testing.T) {
+	r := NewRegistry(10, 0)
+	defer r.CloseAll()
+
+	RegisterTopic[int](r, "topic")
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected registering a different type to panic")
+		}
+	}()
+
+	RegisterTopic[string](r, "topic")
+}
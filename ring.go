@@ -0,0 +1,30 @@
+package broadcast
+
+// A ring keeps the last values pushed into it, up to its capacity.
+type ring[T any] struct {
+	buf   []T
+	start int
+}
+
+// newRing creates a ring that holds up to n values.
+func newRing[T any](n int) *ring[T] {
+	return &ring[T]{buf: make([]T, 0, n)}
+}
+
+// push adds v to the ring, evicting the oldest value if it is full.
+func (r *ring[T]) push(v T) {
+	if len(r.buf) < cap(r.buf) {
+		r.buf = append(r.buf, v)
+		return
+	}
+
+	r.buf[r.start] = v
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+// values returns a copy of the ring's contents, oldest first.
+func (r *ring[T]) values() []T {
+	vs := make([]T, 0, len(r.buf))
+	vs = append(vs, r.buf[r.start:]...)
+	return append(vs, r.buf[:r.start]...)
+}
@@ -0,0 +1,22 @@
+package broadcast
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := newRing[int](3)
+
+	if got := r.values(); len(got) != 0 {
+		t.Errorf("Expected an empty ring, got %v", got)
+	}
+
+	for i := 1; i <= 5; i++ {
+		r.push(i)
+	}
+
+	if got, want := r.values(), []int{3, 4, 5}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
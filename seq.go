@@ -0,0 +1,59 @@
+package broadcast
+
+// A Message is a broadcast value along with its sequence number.
+type Message[T any] struct {
+	// Seq numbers every broadcast value, starting from 1 and increasing
+	// by one for each value, so a gap means that values were missed.
+	// Heartbeats are not numbered and have a Seq of 0, see WithHeartbeat.
+	Seq   uint64
+	Value T
+}
+
+// SubscribeSeq is like Subscribe but delivers every value along with
+// its sequence number, so that a subscriber can tell when it fell
+// behind and missed values. Heartbeats arrive with a Seq of 0. Call
+// cancel to stop receiving values; the channel is closed then, or when
+// the broadcaster closes.
+func (b *Broadcaster[T]) SubscribeSeq(chSize int) (<-chan Message[T], func(), error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{trackSeqs: true, internal: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan Message[T])
+	go func() {
+		defer close(out)
+
+		for v := range sub.out {
+			// NOTE(njern): The sequence number is recorded while the
+			// value is sent, under sub.mu, so it is always there by
+			// the time we get hold of the lock.
+			sub.mu.Lock()
+			seq := sub.seqs[0]
+			sub.seqs = sub.seqs[1:]
+			sub.mu.Unlock()
+
+			select {
+			case out <- Message[T]{Seq: seq, Value: v}:
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+
+	return out, func() { b.Unsubscribe(sub.out) }, nil
+}
+
+// SubscribeFrom is like Subscribe but first delivers the values
+// broadcast after the one with sequence number seq, see Message, so that
+// a subscriber that reconnects can carry on without missing any. It
+// returns ErrDataLost if any of those values are no longer kept by the
+// buffer set up with WithReplay.
+func (b *Broadcaster[T]) SubscribeFrom(chSize int, seq uint64) (<-chan T, error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{resume: true, fromSeq: seq})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub.out, nil
+}
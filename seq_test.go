@@ -0,0 +1,146 @@
+package broadcast
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscribeSeq(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond, WithReplay[int](1))
+	defer b.Close()
+
+	if err := b.Publish(0); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	ch, cancel, err := b.SubscribeSeq(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer cancel()
+
+	// NOTE(njern): The replayed value waits to be read and the next one
+	// fills the buffer, so the last one is dropped.
+	if err := b.PublishBatch([]int{1, 2}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	for seq := uint64(1); seq <= 2; seq++ {
+		m := <-ch
+		if m.Seq != seq || m.Value != int(seq)-1 {
+			t.Errorf("Expected message %d with value %d, got %+v", seq, seq-1, m)
+		}
+	}
+
+	if err := b.Publish(3); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case m := <-ch:
+		if m.Seq != 4 || m.Value != 3 {
+			t.Errorf("Expected message 4 with value 3 after a gap, got %+v", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a message")
+	}
+}
+
+func TestSubscribeSeqCancel(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	ch, cancel, err := b.SubscribeSeq(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("Expected the channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the channel to close")
+	}
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", n)
+	}
+}
+
+func TestSubscribeFrom(t *testing.T) {
+	b := New[int](10, 0, WithReplay[int](3))
+	defer b.Close()
+
+	for i := 1; i <= 5; i++ {
+		if err := b.Publish(i * 10); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	// NOTE(njern): Values 3 to 5 are still retained.
+	ch, err := b.SubscribeFrom(10, 3)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(60); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for _, want := range []int{40, 50, 60} {
+		select {
+		case v := <-ch:
+			if v != want {
+				t.Errorf("Expected %d, got %d", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %d", want)
+		}
+	}
+
+	if _, err := b.SubscribeFrom(10, 1); err != ErrDataLost {
+		t.Errorf("Expected ErrDataLost, got %v", err)
+	}
+}
+
+func TestSubscribeFromWithoutReplay(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	if _, err := b.SubscribeFrom(10, 0); err != nil {
+		t.Errorf("Expected no error before anything was broadcast, got %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if _, err := b.SubscribeFrom(10, 0); err != ErrDataLost {
+		t.Errorf("Expected ErrDataLost, got %v", err)
+	}
+
+	if _, err := b.SubscribeFrom(10, 1); err != nil {
+		t.Errorf("Expected no error when nothing was missed, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package broadcast
+
+// SubscribeSharded subscribes once and splits the broadcast values
+// across shards channels, each with a buffer of size chSize, so they can
+// be consumed by one worker per shard. A value goes to shard
+// key(v) % shards, so values sharing a key always arrive on the same
+// channel, in publish order, while different shards are read in
+// parallel. A shards count below 1 is treated as 1.
+//
+// A shard that falls behind holds up the others once its buffer is
+// full, and the broadcaster's timeout then applies to all of them.
+// Calling cancel stops the routing and closes every shard, and so does
+// closing the broadcaster. Values still on their way to a shard are
+// lost either way.
+func (b *Broadcaster[T]) SubscribeSharded(key func(T) uint64, shards, chSize int) ([]<-chan T, func(), error) {
+	if shards < 1 {
+		shards = 1
+	}
+
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{internal: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lanes := make([]chan T, shards)
+	chs := make([]<-chan T, shards)
+	for i := range lanes {
+		lanes[i] = make(chan T, chSize)
+		chs[i] = lanes[i]
+	}
+
+	go func() {
+		defer func() {
+			for _, lane := range lanes {
+				close(lane)
+			}
+		}()
+
+		// NOTE(njern): A single goroutine routes every value, which is
+		// what keeps each shard in publish order.
+		for v := range sub.out {
+			select {
+			case lanes[key(v)%uint64(shards)] <- v:
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+
+	return chs, func() { b.Unsubscribe(sub.out) }, nil
+}
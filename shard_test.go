@@ -0,0 +1,104 @@
+package broadcast
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+type shardEvent struct {
+	entity uint64
+	n      int
+}
+
+func TestSubscribeSharded(t *testing.T) {
+	b := New[shardEvent](10, 0)
+	defer b.Close()
+
+	chs, cancel, err := b.SubscribeSharded(func(e shardEvent) uint64 { return e.entity }, 3, 2)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer cancel()
+
+	if len(chs) != 3 {
+		t.Fatalf("Expected 3 shards, got %d", len(chs))
+	}
+
+	const entities, perEntity = 5, 50
+
+	var (
+		wg   sync.WaitGroup
+		m    sync.Mutex
+		seen = make(map[uint64][]int)
+		lane = make(map[uint64]int)
+	)
+	wg.Add(entities * perEntity)
+	for i, ch := range chs {
+		go func() {
+			for e := range ch {
+				m.Lock()
+				seen[e.entity] = append(seen[e.entity], e.n)
+				if l, ok := lane[e.entity]; ok && l != i {
+					t.Errorf("Expected entity %d on shard %d, got %d", e.entity, l, i)
+				}
+				lane[e.entity] = i
+				m.Unlock()
+				wg.Done()
+			}
+		}()
+	}
+
+	for n := range perEntity {
+		for entity := range uint64(entities) {
+			if err := b.Publish(shardEvent{entity: entity, n: n}); err != nil {
+				t.Fatalf("Failed to publish: %v", err)
+			}
+		}
+	}
+
+	// NOTE(njern): Closing would drop values still on their way to a
+	// shard, so wait until every value has been read instead.
+	wg.Wait()
+
+	for entity := range uint64(entities) {
+		got := seen[entity]
+		if len(got) != perEntity {
+			t.Fatalf("Expected %d values for entity %d, got %d", perEntity, entity, len(got))
+		}
+
+		for want, n := range got {
+			if n != want {
+				t.Fatalf("Expected value %d for entity %d, got %d", want, entity, n)
+			}
+		}
+	}
+}
+
+func TestSubscribeShardedCancel(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	baseline := runtime.NumGoroutine()
+
+	chs, cancel, err := b.SubscribeSharded(func(v int) uint64 { return uint64(v) }, 2, 0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	cancel()
+
+	for i, ch := range chs {
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Errorf("Expected shard %d to be closed", i)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for shard %d to close", i)
+		}
+	}
+
+	waitForGoroutines(t, baseline)
+}
@@ -0,0 +1,38 @@
+package broadcast
+
+import "sync/atomic"
+
+// Stats is a snapshot of a Broadcaster's delivery counters.
+type Stats struct {
+	Published uint64 // Values broadcast to subscribers
+	Delivered uint64 // Values received by a subscriber
+	Dropped   uint64 // Values a subscriber missed
+	Panics    uint64 // Panics recovered by the broadcast goroutine
+
+	TotalSubscribes   uint64 // Subscribers added
+	TotalUnsubscribes uint64 // Subscribers removed, including by Close
+}
+
+// counters tracks the values reported by Stats.
+type counters struct {
+	published atomic.Uint64
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	panics    atomic.Uint64
+
+	subscribes   atomic.Uint64
+	unsubscribes atomic.Uint64
+}
+
+// Stats returns a snapshot of the broadcaster's delivery counters.
+func (b *Broadcaster[T]) Stats() Stats {
+	return Stats{
+		Published: b.stats.published.Load(),
+		Delivered: b.stats.delivered.Load(),
+		Dropped:   b.stats.dropped.Load(),
+		Panics:    b.stats.panics.Load(),
+
+		TotalSubscribes:   b.stats.subscribes.Load(),
+		TotalUnsubscribes: b.stats.unsubscribes.Load(),
+	}
+}
@@ -0,0 +1,66 @@
+package broadcast
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	b := New[int](10, 10*time.Millisecond)
+	defer b.Close()
+
+	if _, err := b.Subscribe(10); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// NOTE(njern): This subscriber never reads.
+	if _, err := b.Subscribe(0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := b.Publish(i); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	// Allow enough time for timeout and message processing
+	time.Sleep(100 * time.Millisecond)
+
+	want := Stats{Published: 3, Delivered: 3, Dropped: 3, TotalSubscribes: 2}
+	if got := b.Stats(); got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestStatsChurn(t *testing.T) {
+	b := New[int](10, 0)
+
+	var chs []chan int
+	for i := 0; i < 3; i++ {
+		ch, err := b.Subscribe(1)
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		chs = append(chs, ch)
+	}
+
+	b.Unsubscribe(chs[0])
+	b.Unsubscribe(chs[0])
+
+	if _, err := b.Subscribe(1); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if s := b.Stats(); s.TotalSubscribes != 4 || s.TotalUnsubscribes != 1 {
+		t.Errorf("Expected 4 subscribes and 1 unsubscribe, got %d and %d", s.TotalSubscribes, s.TotalUnsubscribes)
+	}
+
+	// NOTE(njern): Close removes the three remaining subscribers.
+	b.Close()
+
+	if s := b.Stats(); s.TotalSubscribes != 4 || s.TotalUnsubscribes != 4 {
+		t.Errorf("Expected 4 subscribes and 4 unsubscribes, got %d and %d", s.TotalSubscribes, s.TotalUnsubscribes)
+	}
+}
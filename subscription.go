@@ -0,0 +1,36 @@
+package broadcast
+
+// A Subscription is a handle to a single subscriber, which separates
+// receiving values from unsubscribing instead of using the channel for
+// both. The zero Subscription is not valid.
+type Subscription[T any] struct {
+	b   *Broadcaster[T]
+	sub *subscriber[T]
+}
+
+// SubscribeHandle is like Subscribe but returns a Subscription.
+func (b *Broadcaster[T]) SubscribeHandle(chSize int) (Subscription[T], error) {
+	sub, err := b.subscribe(chSize, SubscribeOptions[T]{}, subscribeArgs{})
+	if err != nil {
+		return Subscription[T]{}, err
+	}
+
+	return Subscription[T]{b: b, sub: sub}, nil
+}
+
+// ID returns the subscription's ID, see SubscriptionInfo.
+func (s Subscription[T]) ID() uint64 {
+	return s.sub.id
+}
+
+// C returns the channel the subscription receives values on. It is
+// closed once the subscription ends.
+func (s Subscription[T]) C() <-chan T {
+	return s.sub.out
+}
+
+// Close unsubscribes, closing the channel returned by C. It is safe to
+// call more than once.
+func (s Subscription[T]) Close() {
+	s.b.Unsubscribe(s.sub.out)
+}
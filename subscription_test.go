@@ -0,0 +1,97 @@
+package broadcast
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionHandle(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	s, err := b.SubscribeHandle(10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case v := <-s.C():
+		if v != 1 {
+			t.Errorf("Expected 1, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a value")
+	}
+
+	s.Close()
+	s.Close()
+
+	if _, ok := <-s.C(); ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", n)
+	}
+}
+
+func TestSubscriptionHandleClosed(t *testing.T) {
+	b := New[int](10, 0)
+	b.Close()
+
+	if _, err := b.SubscribeHandle(10); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	b := New[int](10, 0)
+	defer b.Close()
+
+	a, err := b.SubscribeHandle(5)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if _, err := b.SubscribeWithOptions(3, SubscribeOptions[int]{Tags: []string{"admin"}}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	d, err := b.SubscribeHandle(1)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	a.Close()
+
+	if err := b.Publish(1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	infos := b.Subscriptions()
+	if len(infos) != 2 {
+		t.Fatalf("Expected 2 subscriptions, got %d", len(infos))
+	}
+
+	if got := infos[0]; got.Cap != 3 || got.Backlog != 1 || !slices.Equal(got.Tags, []string{"admin"}) {
+		t.Errorf("Expected the tagged subscription first, got %+v", got)
+	}
+
+	if got := infos[1]; got.ID != d.ID() || got.Cap != 1 || got.Backlog != 1 {
+		t.Errorf("Expected the last subscription second, got %+v", got)
+	}
+
+	if infos[0].ID == a.ID() || infos[0].ID == d.ID() {
+		t.Errorf("Expected unique IDs, got %d", infos[0].ID)
+	}
+}
@@ -0,0 +1,173 @@
+package broadcast
+
+import (
+	"path"
+	"sync"
+	"time"
+)
+
+// A TopicBroadcaster routes values to subscribers by topic name. Each
+// topic is served by its own Broadcaster, created on the first
+// Subscribe and closed once its last subscriber unsubscribes.
+//
+// Subscribers may also subscribe to every topic matching a pattern, see
+// SubscribePattern.
+type TopicBroadcaster[T any] struct {
+	m        sync.Mutex // Protects the topics and patterns maps
+	topics   map[string]*Broadcaster[T]
+	patterns map[string]*Broadcaster[T]
+	closed   bool
+	n        int
+	timeout  time.Duration
+	opts     []Option[T]
+}
+
+// NewTopicBroadcaster creates a new TopicBroadcaster whose topics are
+// created as if by New(n, timeout, opts...).
+func NewTopicBroadcaster[T any](n int, timeout time.Duration, opts ...Option[T]) *TopicBroadcaster[T] {
+	return &TopicBroadcaster[T]{
+		topics:   make(map[string]*Broadcaster[T]),
+		patterns: make(map[string]*Broadcaster[T]),
+		n:        n,
+		timeout:  timeout,
+		opts:     opts,
+	}
+}
+
+// Publish sends a value to all subscribers of the topic, including
+// those subscribed to a matching pattern. Values published to a topic
+// without subscribers are discarded.
+func (tb *TopicBroadcaster[T]) Publish(topic string, v T) error {
+	tb.m.Lock()
+	if tb.closed {
+		tb.m.Unlock()
+		return ErrBroadcasterClosed
+	}
+
+	var bs []*Broadcaster[T]
+	if b, ok := tb.topics[topic]; ok {
+		bs = append(bs, b)
+	}
+
+	// NOTE(njern): Only pay for matching when there are patterns.
+	if len(tb.patterns) > 0 {
+		for pattern, b := range tb.patterns {
+			if ok, _ := path.Match(pattern, topic); ok {
+				bs = append(bs, b)
+			}
+		}
+	}
+	tb.m.Unlock()
+
+	for _, b := range bs {
+		// NOTE(njern): ErrBroadcasterClosed means the last subscriber
+		// left while we were publishing, which is no different from
+		// there being no subscribers at all.
+		if err := b.Publish(v); err != nil && err != ErrBroadcasterClosed {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Subscribe adds a new subscriber to the topic and returns a channel to listen on.
+func (tb *TopicBroadcaster[T]) Subscribe(topic string, chSize int) (chan T, error) {
+	tb.m.Lock()
+	defer tb.m.Unlock()
+
+	if tb.closed {
+		return nil, ErrBroadcasterClosed
+	}
+
+	return tb.subscribe(tb.topics, topic, chSize)
+}
+
+// SubscribePattern adds a new subscriber to every topic whose name
+// matches pattern, using the syntax of path.Match, so that "metrics.*"
+// matches "metrics.cpu" and "metrics.mem". It returns path.ErrBadPattern
+// if the pattern is malformed.
+func (tb *TopicBroadcaster[T]) SubscribePattern(pattern string, chSize int) (chan T, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	tb.m.Lock()
+	defer tb.m.Unlock()
+
+	if tb.closed {
+		return nil, ErrBroadcasterClosed
+	}
+
+	return tb.subscribe(tb.patterns, pattern, chSize)
+}
+
+// subscribe adds a new subscriber to the broadcaster stored under key,
+// creating it if needed. A broadcaster created for a subscription that
+// then fails is torn down again. The caller must hold the lock.
+func (tb *TopicBroadcaster[T]) subscribe(bs map[string]*Broadcaster[T], key string, chSize int) (chan T, error) {
+	b, ok := bs[key]
+	if !ok {
+		b = New(tb.n, tb.timeout, tb.opts...)
+		bs[key] = b
+	}
+
+	ch, err := b.Subscribe(chSize)
+	if err != nil && !ok {
+		delete(bs, key)
+		b.Close()
+	}
+
+	return ch, err
+}
+
+// Unsubscribe removes a subscriber from the topic, tearing the topic
+// down if it was the last one.
+func (tb *TopicBroadcaster[T]) Unsubscribe(topic string, ch chan<- T) {
+	tb.m.Lock()
+	defer tb.m.Unlock()
+
+	unsubscribe(tb.topics, topic, ch)
+}
+
+// UnsubscribePattern removes a subscriber added by SubscribePattern.
+func (tb *TopicBroadcaster[T]) UnsubscribePattern(pattern string, ch chan<- T) {
+	tb.m.Lock()
+	defer tb.m.Unlock()
+
+	unsubscribe(tb.patterns, pattern, ch)
+}
+
+// unsubscribe removes a subscriber from the broadcaster stored under
+// key, tearing it down if it was the last one. The caller must hold the
+// lock.
+func unsubscribe[T any](bs map[string]*Broadcaster[T], key string, ch chan<- T) {
+	b, ok := bs[key]
+	if !ok {
+		return
+	}
+
+	b.Unsubscribe(ch)
+	if b.SubscriberCount() == 0 {
+		delete(bs, key)
+		b.Close()
+	}
+}
+
+// Close all topics and their subscriber channels.
+func (tb *TopicBroadcaster[T]) Close() {
+	tb.m.Lock()
+	defer tb.m.Unlock()
+
+	for _, b := range tb.topics {
+		b.Close()
+	}
+
+	for _, b := range tb.patterns {
+		b.Close()
+	}
+
+	tb.topics = nil
+	tb.patterns = nil
+	tb.closed = true
+}
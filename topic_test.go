@@ -0,0 +1,211 @@
+package broadcast
+
+import (
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTopicBroadcasterIsolation(t *testing.T) {
+	tb := NewTopicBroadcaster[int](10, 0)
+	defer tb.Close()
+
+	fooCh, err := tb.Subscribe("foo", 10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	barCh, err := tb.Subscribe("bar", 10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := tb.Publish("foo", 1); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if err := tb.Publish("bar", 2); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for subCh, want := range map[chan int]int{fooCh: 1, barCh: 2} {
+		select {
+		case val := <-subCh:
+			if val != want {
+				t.Errorf("Expected to receive %d, got %d", want, val)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Expected to receive %d but did not", want)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if len(fooCh) != 0 || len(barCh) != 0 {
+		t.Errorf("Expected each topic to only receive its own messages")
+	}
+}
+
+func TestTopicBroadcasterCleanup(t *testing.T) {
+	tb := NewTopicBroadcaster[int](10, 0)
+	defer tb.Close()
+
+	subCh, err := tb.Subscribe("foo", 10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	tb.Unsubscribe("foo", subCh)
+
+	if len(tb.topics) != 0 {
+		t.Errorf("Expected topic to be torn down, got %d topics", len(tb.topics))
+	}
+
+	if err := tb.Publish("foo", 1); err != nil {
+		t.Errorf("Expected publishing to a topic without subscribers to succeed, got %v", err)
+	}
+}
+
+func TestTopicBroadcasterConcurrentSubscribe(t *testing.T) {
+	tb := NewTopicBroadcaster[int](10, 0)
+	defer tb.Close()
+
+	const workers = 20
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				subCh, err := tb.Subscribe("foo", 1)
+				if err != nil {
+					t.Errorf("Failed to subscribe: %v", err)
+					return
+				}
+
+				tb.Unsubscribe("foo", subCh)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(tb.topics) != 0 {
+		t.Errorf("Expected topic to be torn down, got %d topics", len(tb.topics))
+	}
+}
+
+func TestTopicBroadcasterClosed(t *testing.T) {
+	tb := NewTopicBroadcaster[int](10, 0)
+
+	subCh, err := tb.Subscribe("foo", 10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	tb.Close()
+
+	if _, ok := <-subCh; ok {
+		t.Errorf("Expected subscriber channel to be closed but it was still open")
+	}
+
+	if _, err := tb.Subscribe("foo", 10); err != ErrBroadcasterClosed {
+		t.Errorf("Expected ErrBroadcasterClosed, got %v", err)
+	}
+}
+
+func TestTopicBroadcasterPattern(t *testing.T) {
+	tb := NewTopicBroadcaster[string](10, 0)
+	defer tb.Close()
+
+	metrics, err := tb.SubscribePattern("metrics.*", 10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	cpu, err := tb.Subscribe("metrics.cpu", 10)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for _, topic := range []string{"metrics.cpu", "metrics.mem", "logs.app"} {
+		if err := tb.Publish(topic, topic); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if v := <-cpu; v != "metrics.cpu" {
+		t.Errorf("Expected metrics.cpu, got %s", v)
+	}
+
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case v := <-metrics:
+			got[v] = true
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for value %d", i)
+		}
+	}
+
+	if !got["metrics.cpu"] || !got["metrics.mem"] {
+		t.Errorf("Expected metrics.cpu and metrics.mem, got %v", got)
+	}
+
+	select {
+	case v := <-metrics:
+		t.Errorf("Expected no more values, got %s", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	tb.UnsubscribePattern("metrics.*", metrics)
+	if _, ok := <-metrics; ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+}
+
+func TestTopicBroadcasterBadPattern(t *testing.T) {
+	tb := NewTopicBroadcaster[string](10, 0)
+	defer tb.Close()
+
+	if _, err := tb.SubscribePattern("[", 10); err != path.ErrBadPattern {
+		t.Errorf("Expected path.ErrBadPattern, got %v", err)
+	}
+}
+
+func TestTopicBroadcasterSubscribeFails(t *testing.T) {
+	tb := NewTopicBroadcaster[int](10, 0)
+	defer tb.Close()
+
+	if _, err := tb.Subscribe("a", -1); err != ErrInvalidBufferSize {
+		t.Errorf("Expected ErrInvalidBufferSize, got %v", err)
+	}
+
+	if _, err := tb.SubscribePattern("a.*", -1); err != ErrInvalidBufferSize {
+		t.Errorf("Expected ErrInvalidBufferSize, got %v", err)
+	}
+
+	if n := len(tb.topics); n != 0 {
+		t.Errorf("Expected 0 topics, got %d", n)
+	}
+
+	if n := len(tb.patterns); n != 0 {
+		t.Errorf("Expected 0 patterns, got %d", n)
+	}
+
+	// NOTE(njern): A topic that already has subscribers stays.
+	if _, err := tb.Subscribe("a", 10); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if _, err := tb.Subscribe("a", -1); err != ErrInvalidBufferSize {
+		t.Errorf("Expected ErrInvalidBufferSize, got %v", err)
+	}
+
+	if n := len(tb.topics); n != 1 {
+		t.Errorf("Expected 1 topic, got %d", n)
+	}
+}